@@ -8,3 +8,13 @@ type Account struct {
 	SecretKey string
 	Token     string
 }
+
+// IsTrusted returns true if the account number is one of the trusted account numbers
+func IsTrusted(number string, trusted []string) bool {
+	for _, t := range trusted {
+		if t == number {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package eip
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+)
+
+// AE represents Elastic IPs per account.
+type AE struct {
+	Account oaws.Account
+	Group   []AddressGroup
+}
+
+// AddressGroup represents Elastic IPs grouped by region
+type AddressGroup struct {
+	Region    string
+	Addresses []*ec2.Address
+}
+
+// List returns all Elastic IPs of the account in all regions
+func List(account oaws.Account, regions []string) *AE {
+	ae := &AE{Account: account}
+
+	c := make(chan *AddressGroup)
+	defer close(c)
+
+	for _, region := range regions {
+		go func(region string) {
+			ag := &AddressGroup{Region: region}
+			descAddresses, err := oec2.ClientWithRegion(account, region).DescribeAddresses(&ec2.DescribeAddressesInput{})
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"Account": account.Name,
+					"Region":  region,
+				}).Warnf("could not describe addresses: %+v", err)
+				c <- ag
+				return
+			}
+			ag.Addresses = descAddresses.Addresses
+			c <- ag
+		}(region)
+	}
+
+	for ridx, r := range regions {
+		logrus.WithFields(logrus.Fields{
+			"Account":      account.Name,
+			"Region":       r,
+			"Region Index": ridx,
+		}).Debugln("Retrieving Elastic IPs...")
+		select {
+		case ag := <-c:
+			ae.Group = append(ae.Group, *ag)
+		}
+	}
+	return ae
+}
+
+// IPs returns the public IPs of all Elastic IPs
+func (ae *AE) IPs() []string {
+	var ips []string
+	for _, group := range ae.Group {
+		for _, a := range group.Addresses {
+			if a.PublicIp != nil {
+				ips = append(ips, *a.PublicIp)
+			}
+		}
+	}
+	return ips
+}
+
+// CheckPolicy returns Elastic IPs that are not associated with an instance or network interface
+func (ae *AE) CheckPolicy() *AE {
+	violations := &AE{Account: ae.Account}
+	for _, group := range ae.Group {
+		violatingGroup := AddressGroup{Region: group.Region}
+		for _, a := range group.Addresses {
+			if a.AssociationId == nil && a.InstanceId == nil && a.NetworkInterfaceId == nil {
+				violatingGroup.Addresses = append(violatingGroup.Addresses, a)
+			}
+		}
+		violations.Group = append(violations.Group, violatingGroup)
+	}
+	return violations
+}
@@ -0,0 +1,217 @@
+package hygiene
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/instances"
+)
+
+// Policy represents the instance hygiene policy.
+// A zero value disables the corresponding rule.
+type Policy struct {
+	MaxStoppedDays  int
+	MaxImageAgeDays int
+	RequiredTags    []string
+}
+
+// HI represents instances per account along with the creation dates of their AMIs.
+type HI struct {
+	Account oaws.Account
+	Group   []InstanceGroup
+}
+
+// InstanceGroup represents instances and the creation dates of their AMIs, keyed by AMI ID, grouped by region.
+// MissingImages holds the AMI IDs of instances that could not be found, because the AMIs were deregistered
+// or are no longer shared with the account.
+type InstanceGroup struct {
+	Region        string
+	Instances     []*ec2.Instance
+	ImageDates    map[string]time.Time
+	MissingImages map[string]bool
+}
+
+// HV represents instances per account that are violating the hygiene policy.
+type HV struct {
+	Account oaws.Account
+	Group   []ViolationGroup
+}
+
+// ViolationGroup represents hygiene violations grouped by region
+type ViolationGroup struct {
+	Region     string
+	Violations []Violation
+}
+
+// Violation represents an instance violating a hygiene rule
+type Violation struct {
+	Instance *ec2.Instance
+	Rule     string
+	Detail   string
+}
+
+// maxImageIDs is the max no. of values of a DescribeImages filter
+const maxImageIDs = 200
+
+var stoppedAtRe = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) GMT\)`)
+
+// List retrieves the creation dates of the AMIs of all instances found by instances.List
+func List(iv *instances.IV) *HI {
+	hi := &HI{Account: iv.Account}
+
+	c := make(chan *InstanceGroup)
+	defer close(c)
+
+	for _, group := range iv.Group {
+		go func(g instances.InstanceGroup) {
+			c <- listRegion(iv.Account, g)
+		}(group)
+	}
+
+	for idx, g := range iv.Group {
+		logrus.Debugf("[%d] Retrieving AMI creation dates in Region [%s]", idx, g.Region)
+		select {
+		case ig := <-c:
+			hi.Group = append(hi.Group, *ig)
+		}
+	}
+	return hi
+}
+
+func listRegion(account oaws.Account, g instances.InstanceGroup) *InstanceGroup {
+	ig := &InstanceGroup{Region: g.Region, Instances: g.Instances, ImageDates: make(map[string]time.Time), MissingImages: make(map[string]bool)}
+	if len(g.Instances) == 0 {
+		return ig
+	}
+
+	ids := make(map[string]bool)
+	var imageIds []*string
+	for _, i := range g.Instances {
+		if i.ImageId != nil && !ids[*i.ImageId] {
+			ids[*i.ImageId] = true
+			imageIds = append(imageIds, i.ImageId)
+		}
+	}
+
+	// an image-id filter ignores missing AMIs, unlike ImageIds, which fails the whole call on any deregistered AMI
+	client := oec2.ClientWithRegion(account, g.Region)
+	for start := 0; start < len(imageIds); start += maxImageIDs {
+		end := start + maxImageIDs
+		if end > len(imageIds) {
+			end = len(imageIds)
+		}
+		descImages, err := client.DescribeImages(&ec2.DescribeImagesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("image-id"), Values: imageIds[start:end]}},
+		})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Account": account.Name,
+				"Region":  g.Region,
+			}).Warnf("could not describe images: %+v", err)
+			return ig
+		}
+		for _, image := range descImages.Images {
+			delete(ids, *image.ImageId)
+			created, err := time.Parse(time.RFC3339, aws.StringValue(image.CreationDate))
+			if err != nil {
+				logrus.Debugf("Could not parse creation date of AMI [%s]: %+v", *image.ImageId, err)
+				continue
+			}
+			ig.ImageDates[*image.ImageId] = created
+		}
+	}
+	for id := range ids {
+		ig.MissingImages[id] = true
+	}
+	return ig
+}
+
+// CheckPolicy returns instances that are stopped for too long, run stale or missing AMIs, or are missing required tags
+func (hi *HI) CheckPolicy(p Policy) *HV {
+	violations := &HV{Account: hi.Account}
+	maxStopped := time.Duration(p.MaxStoppedDays) * 24 * time.Hour
+	maxImageAge := time.Duration(p.MaxImageAgeDays) * 24 * time.Hour
+
+	for _, group := range hi.Group {
+		vg := ViolationGroup{Region: group.Region}
+		for _, i := range group.Instances {
+			logrus.Debugf("Checking hygiene of Instance [%s] in Account [%s] in Region [%s]", *i.InstanceId, hi.Account.Name, group.Region)
+
+			if p.MaxStoppedDays > 0 {
+				if stoppedAt, ok := stoppedSince(i); ok && time.Since(stoppedAt) > maxStopped {
+					vg.Violations = append(vg.Violations, Violation{
+						Instance: i,
+						Rule:     "Long-Stopped Instance",
+						Detail:   fmt.Sprintf("stopped since %s", stoppedAt.Format("2006-01-02")),
+					})
+				}
+			}
+
+			if p.MaxImageAgeDays > 0 && i.ImageId != nil && group.MissingImages[*i.ImageId] {
+				vg.Violations = append(vg.Violations, Violation{
+					Instance: i,
+					Rule:     "Missing AMI",
+					Detail:   fmt.Sprintf("AMI %s is deregistered or no longer shared", *i.ImageId),
+				})
+			} else if p.MaxImageAgeDays > 0 && i.ImageId != nil {
+				if created, ok := group.ImageDates[*i.ImageId]; ok && time.Since(created) > maxImageAge {
+					vg.Violations = append(vg.Violations, Violation{
+						Instance: i,
+						Rule:     "Stale AMI",
+						Detail:   fmt.Sprintf("AMI %s created on %s", *i.ImageId, created.Format("2006-01-02")),
+					})
+				}
+			}
+
+			if missing := missingTags(i, p.RequiredTags); len(missing) > 0 {
+				vg.Violations = append(vg.Violations, Violation{
+					Instance: i,
+					Rule:     "Missing Required Tags",
+					Detail:   fmt.Sprintf("%v", missing),
+				})
+			}
+		}
+		violations.Group = append(violations.Group, vg)
+	}
+	return violations
+}
+
+// stoppedSince parses the time a stopped instance was stopped from its state transition reason,
+// e.g. "User initiated (2017-06-21 18:06:50 GMT)".
+func stoppedSince(i *ec2.Instance) (time.Time, bool) {
+	if i.State == nil || aws.StringValue(i.State.Name) != ec2.InstanceStateNameStopped {
+		return time.Time{}, false
+	}
+	m := stoppedAtRe.FindStringSubmatch(aws.StringValue(i.StateTransitionReason))
+	if m == nil {
+		logrus.Debugf("Could not determine when Instance [%s] was stopped", *i.InstanceId)
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", m[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func missingTags(i *ec2.Instance, required []string) []string {
+	tags := make(map[string]bool)
+	for _, tag := range i.Tags {
+		if aws.StringValue(tag.Value) != "" {
+			tags[aws.StringValue(tag.Key)] = true
+		}
+	}
+	var missing []string
+	for _, key := range required {
+		if !tags[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
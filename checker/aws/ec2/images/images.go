@@ -0,0 +1,128 @@
+package images
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+)
+
+// AI represents AMIs per account.
+type AI struct {
+	Account oaws.Account
+	Group   []ImageGroup
+}
+
+// ImageGroup represents AMIs grouped by region
+type ImageGroup struct {
+	Region string
+	Images []Image
+}
+
+// Image represents an AMI and the accounts it is shared with.
+// When returned by CheckPolicy, SharedWith only holds accounts outside of the trusted accounts.
+type Image struct {
+	*ec2.Image
+	Public     bool
+	SharedWith []string
+}
+
+// List returns AMIs owned by the account, along with their launch permissions, in all regions
+func List(account oaws.Account, regions []string) *AI {
+	ai := &AI{Account: account}
+
+	c := make(chan *ImageGroup)
+	defer close(c)
+
+	for _, region := range regions {
+		go func(region string) {
+			c <- listRegion(account, region)
+		}(region)
+	}
+
+	for ridx, r := range regions {
+		logrus.WithFields(logrus.Fields{
+			"Account":      account.Name,
+			"Region":       r,
+			"Region Index": ridx,
+		}).Debugln("Retrieving AMIs...")
+		select {
+		case ig := <-c:
+			ai.Group = append(ai.Group, *ig)
+		}
+	}
+	return ai
+}
+
+func listRegion(account oaws.Account, region string) *ImageGroup {
+	ig := &ImageGroup{Region: region}
+	client := oec2.ClientWithRegion(account, region)
+
+	descImages, err := client.DescribeImages(&ec2.DescribeImagesInput{Owners: []*string{aws.String("self")}})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"Account": account.Name,
+			"Region":  region,
+		}).Warnf("could not describe images: %+v", err)
+		return ig
+	}
+
+	for _, image := range descImages.Images {
+		i := Image{Image: image, Public: aws.BoolValue(image.Public)}
+		attr, err := client.DescribeImageAttribute(&ec2.DescribeImageAttributeInput{
+			Attribute: aws.String("launchPermission"),
+			ImageId:   image.ImageId,
+		})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Account":  account.Name,
+				"Region":   region,
+				"Image-ID": *image.ImageId,
+			}).Warnf("could not describe image launch permissions: %+v", err)
+			ig.Images = append(ig.Images, i)
+			continue
+		}
+		for _, perm := range attr.LaunchPermissions {
+			if aws.StringValue(perm.Group) == "all" {
+				i.Public = true
+			}
+			if perm.UserId != nil {
+				i.SharedWith = append(i.SharedWith, *perm.UserId)
+			}
+		}
+		ig.Images = append(ig.Images, i)
+	}
+	logrus.Debugf("Listed %d AMIs in Account [%s] in Region [%s]", len(ig.Images), account.Name, region)
+	return ig
+}
+
+// CheckPolicy returns AMIs that are public or shared with accounts outside of the trusted accounts
+func (ai *AI) CheckPolicy(trusted []string) *AI {
+	violations := &AI{Account: ai.Account}
+	for _, group := range ai.Group {
+		violatingGroup := ImageGroup{Region: group.Region}
+		for _, image := range group.Images {
+			external := untrusted(image.SharedWith, trusted)
+			if image.Public || len(external) > 0 {
+				violatingGroup.Images = append(violatingGroup.Images, Image{
+					Image:      image.Image,
+					Public:     image.Public,
+					SharedWith: external,
+				})
+			}
+		}
+		violations.Group = append(violations.Group, violatingGroup)
+	}
+	return violations
+}
+
+func untrusted(accounts, trusted []string) []string {
+	var external []string
+	for _, account := range accounts {
+		if !oaws.IsTrusted(account, trusted) {
+			external = append(external, account)
+		}
+	}
+	return external
+}
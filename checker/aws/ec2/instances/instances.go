@@ -1,10 +1,16 @@
 package instances
 
 import (
+	"strings"
+	"sync"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
 	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
 )
 
 // IV represents instances per account that are violating policies.
@@ -13,10 +19,12 @@ type IV struct {
 	Group   []InstanceGroup
 }
 
-// InstanceGroup represents instances grouped by region
+// InstanceGroup represents instances grouped by region.
+// Profiles is only populated by CheckPolicy and is keyed by instance ID.
 type InstanceGroup struct {
 	Region    string
 	Instances []*ec2.Instance
+	Profiles  map[string]*Profile
 }
 
 // List returns a
@@ -32,7 +40,12 @@ func List(account oaws.Account, regions []string) *IV {
 		go func(region string) {
 			descInstances, err := oec2.ClientWithRegion(account, region).DescribeInstances(&ec2.DescribeInstancesInput{})
 			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"Account": account.Name,
+					"Region":  region,
+				}).Warnf("could not describe instances: %+v", err)
 				c <- ig
+				return
 			}
 			for _, res := range descInstances.Reservations {
 				for _, ri := range res.Instances {
@@ -53,23 +66,36 @@ func List(account oaws.Account, regions []string) *IV {
 	return violations
 }
 
-// CheckPolicy returns EC2 instances violating policies
+// BroadPermissions are permissions that make an internet-exposed instance a high severity finding
+var BroadPermissions = []string{"*:*", "iam:*", "s3:*"}
+
+// Profile represents the IAM roles of an instance profile and the broad permissions they hold
+type Profile struct {
+	Arn              string
+	Roles            []string
+	BroadPermissions []string
+}
+
+// CheckPolicy returns public EC2 instances, along with their instance profiles
 func (iv *IV) CheckPolicy() *IV {
 	var violations IV
 	igc := make(chan *InstanceGroup)
 	defer close(igc)
+
+	profiles := newProfileCache(iv.Account)
 	for _, group := range iv.Group {
 		violations.Account = iv.Account
 		go func(account oaws.Account, g InstanceGroup) {
 			logrus.Debugf("Checking EC2 Policies in Account[%s] in Region [%s]", account.Name, g.Region)
-			igv := &InstanceGroup{Region: g.Region}
-			if len(g.Instances) == 0 {
-				igc <- igv
-				return
-			}
+			igv := &InstanceGroup{Region: g.Region, Profiles: make(map[string]*Profile)}
 			for _, i := range g.Instances {
-				// logrus.Println(i.SecurityGroups)
-				igv.Instances = append(g.Instances, i)
+				if !isPublic(i) {
+					continue
+				}
+				igv.Instances = append(igv.Instances, i)
+				if i.IamInstanceProfile != nil && i.IamInstanceProfile.Arn != nil {
+					igv.Profiles[*i.InstanceId] = profiles.get(*i.IamInstanceProfile.Arn)
+				}
 			}
 			igc <- igv
 		}(iv.Account, group)
@@ -87,6 +113,84 @@ func (iv *IV) CheckPolicy() *IV {
 }
 
 func isPublic(i *ec2.Instance) bool {
+	return i.PublicIpAddress != nil
+}
+
+// profileCache resolves instance profiles once per account, since many instances share a profile
+type profileCache struct {
+	sync.Mutex
+	account  oaws.Account
+	client   *iam.IAM
+	profiles map[string]*Profile
+}
+
+func newProfileCache(account oaws.Account) *profileCache {
+	return &profileCache{
+		account:  account,
+		client:   oiam.Client(account),
+		profiles: make(map[string]*Profile),
+	}
+}
 
-	return false
+func (pc *profileCache) get(arn string) *Profile {
+	pc.Lock()
+	defer pc.Unlock()
+	if p, ok := pc.profiles[arn]; ok {
+		return p
+	}
+	p := pc.resolve(arn)
+	pc.profiles[arn] = p
+	return p
+}
+
+func (pc *profileCache) resolve(arn string) *Profile {
+	p := &Profile{Arn: arn}
+	name := arn[strings.LastIndex(arn, "/")+1:]
+	ip, err := pc.client.GetInstanceProfile(&iam.GetInstanceProfileInput{InstanceProfileName: &name})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"Account": pc.account.Name,
+			"Profile": arn,
+		}).Warnf("could not get instance profile: %+v", err)
+		return p
+	}
+
+	broad := make(map[string]bool)
+	for _, role := range ip.InstanceProfile.Roles {
+		p.Roles = append(p.Roles, *role.RoleName)
+		for _, perm := range pc.rolePermissions(*role.RoleName) {
+			broad[perm] = true
+		}
+	}
+	for _, perm := range BroadPermissions {
+		if broad[perm] {
+			p.BroadPermissions = append(p.BroadPermissions, perm)
+		}
+	}
+	return p
+}
+
+// rolePermissions returns the BroadPermissions granted by the role policies, less those denied by them,
+// and limited to those also granted by the permissions boundary of the role if it has one
+func (pc *profileCache) rolePermissions(role string) []string {
+	fields := logrus.Fields{"Account": pc.account.Name, "Role": role}
+	docs, err := oiam.RolePolicies(pc.client, role)
+	if err != nil {
+		logrus.WithFields(fields).Warnf("could not get role policies: %+v", err)
+		return nil
+	}
+	var documents []*policy.Document
+	for _, doc := range docs {
+		documents = append(documents, doc)
+	}
+	perms := policy.Broad(documents, BroadPermissions)
+
+	boundary, err := oiam.RoleBoundary(pc.client, role)
+	if err != nil {
+		logrus.WithFields(fields).Warnf("could not get role permissions boundary: %+v", err)
+	} else if boundary != nil {
+		perms = policy.Broad([]*policy.Document{boundary}, perms)
+	}
+	logrus.Debugf("Role [%s] is granted %v", role, perms)
+	return perms
 }
@@ -0,0 +1,132 @@
+package userdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/instances"
+	"github.com/petermbenjamin/orthrus/checker/secrets"
+)
+
+// UD represents the user data of instances per account.
+type UD struct {
+	Account oaws.Account
+	Group   []UserDataGroup
+}
+
+// UserDataGroup represents instance user data grouped by region
+type UserDataGroup struct {
+	Region   string
+	UserData []UserData
+}
+
+// UserData represents the decoded user data of an instance.
+// Matches is only populated by CheckPolicy.
+type UserData struct {
+	InstanceID string
+	Data       []byte
+	Matches    []secrets.Match
+}
+
+// List retrieves and decodes the user data of all instances found by instances.List
+func List(iv *instances.IV) *UD {
+	ud := &UD{Account: iv.Account}
+
+	c := make(chan *UserDataGroup)
+	defer close(c)
+
+	for _, group := range iv.Group {
+		go func(g instances.InstanceGroup) {
+			c <- listRegion(iv.Account, g)
+		}(group)
+	}
+
+	for idx, g := range iv.Group {
+		logrus.Debugf("[%d] Retrieving user data in Region [%s]", idx, g.Region)
+		select {
+		case udg := <-c:
+			ud.Group = append(ud.Group, *udg)
+		}
+	}
+	return ud
+}
+
+func listRegion(account oaws.Account, g instances.InstanceGroup) *UserDataGroup {
+	udg := &UserDataGroup{Region: g.Region}
+	if len(g.Instances) == 0 {
+		return udg
+	}
+
+	client := oec2.ClientWithRegion(account, g.Region)
+	for _, i := range g.Instances {
+		attr, err := client.DescribeInstanceAttribute(&ec2.DescribeInstanceAttributeInput{
+			Attribute:  aws.String("userData"),
+			InstanceId: i.InstanceId,
+		})
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Account":     account.Name,
+				"Region":      g.Region,
+				"Instance-ID": *i.InstanceId,
+			}).Warnf("could not describe instance user data: %+v", err)
+			continue
+		}
+		if attr.UserData == nil || attr.UserData.Value == nil {
+			continue
+		}
+		data, err := decode(*attr.UserData.Value)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"Account":     account.Name,
+				"Region":      g.Region,
+				"Instance-ID": *i.InstanceId,
+			}).Warnf("could not decode instance user data: %+v", err)
+			continue
+		}
+		udg.UserData = append(udg.UserData, UserData{InstanceID: *i.InstanceId, Data: data})
+	}
+	return udg
+}
+
+// decode base64-decodes user data and decompresses it if it is gzipped
+func decode(value string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// CheckPolicy returns the user data of instances that contain secrets found by the detectors
+func (ud *UD) CheckPolicy(detectors []secrets.Detector) *UD {
+	violations := &UD{Account: ud.Account}
+	for _, group := range ud.Group {
+		violatingGroup := UserDataGroup{Region: group.Region}
+		for _, u := range group.UserData {
+			logrus.Debugf("Scanning user data of Instance [%s] in Account [%s] in Region [%s]", u.InstanceID, ud.Account.Name, group.Region)
+			if matches := secrets.Scan(u.Data, detectors); len(matches) > 0 {
+				violatingGroup.UserData = append(violatingGroup.UserData, UserData{
+					InstanceID: u.InstanceID,
+					Matches:    matches,
+				})
+			}
+		}
+		violations.Group = append(violations.Group, violatingGroup)
+	}
+	return violations
+}
@@ -0,0 +1,162 @@
+package vpc
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oec2 "github.com/petermbenjamin/orthrus/checker/aws/ec2"
+)
+
+// AV represents VPCs per account.
+type AV struct {
+	Account oaws.Account
+	Group   []VPCGroup
+}
+
+// VPCGroup represents VPCs, their flow logs and peering connections grouped by region.
+// FlowLogs and Interfaces are keyed by VPC ID.
+type VPCGroup struct {
+	Region     string
+	VPCs       []*ec2.Vpc
+	FlowLogs   map[string][]*ec2.FlowLog
+	Interfaces map[string]int
+	Peerings   []*ec2.VpcPeeringConnection
+}
+
+// VV represents VPC violations per account.
+type VV struct {
+	Account oaws.Account
+	Group   []ViolationGroup
+}
+
+// ViolationGroup represents VPC violations grouped by region
+type ViolationGroup struct {
+	Region string
+	// NoFlowLogs holds VPCs without flow logs
+	NoFlowLogs []*ec2.Vpc
+	// DefaultVPCs holds default VPCs that contain network interfaces
+	DefaultVPCs []*ec2.Vpc
+	// ExternalPeerings holds active peering connections with accounts outside of the trusted accounts
+	ExternalPeerings []*ec2.VpcPeeringConnection
+}
+
+// List returns all VPCs of the account, along with their flow logs and peering connections, in all regions
+func List(account oaws.Account, regions []string) *AV {
+	av := &AV{Account: account}
+
+	c := make(chan *VPCGroup)
+	defer close(c)
+
+	for _, region := range regions {
+		go func(region string) {
+			c <- listRegion(account, region)
+		}(region)
+	}
+
+	for ridx, r := range regions {
+		logrus.WithFields(logrus.Fields{
+			"Account":      account.Name,
+			"Region":       r,
+			"Region Index": ridx,
+		}).Debugln("Retrieving VPCs...")
+		select {
+		case vg := <-c:
+			av.Group = append(av.Group, *vg)
+		}
+	}
+	return av
+}
+
+func listRegion(account oaws.Account, region string) *VPCGroup {
+	vg := &VPCGroup{
+		Region:     region,
+		FlowLogs:   make(map[string][]*ec2.FlowLog),
+		Interfaces: make(map[string]int),
+	}
+	fields := logrus.Fields{"Account": account.Name, "Region": region}
+	client := oec2.ClientWithRegion(account, region)
+
+	descVpcs, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{})
+	if err != nil {
+		logrus.WithFields(fields).Warnf("could not describe VPCs: %+v", err)
+		return vg
+	}
+	vg.VPCs = descVpcs.Vpcs
+
+	descFlowLogs, err := client.DescribeFlowLogs(&ec2.DescribeFlowLogsInput{})
+	if err != nil {
+		logrus.WithFields(fields).Warnf("could not describe flow logs: %+v", err)
+	} else {
+		for _, fl := range descFlowLogs.FlowLogs {
+			id := aws.StringValue(fl.ResourceId)
+			vg.FlowLogs[id] = append(vg.FlowLogs[id], fl)
+		}
+	}
+
+	for _, v := range vg.VPCs {
+		if !aws.BoolValue(v.IsDefault) {
+			continue
+		}
+		descENIs, err := client.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("vpc-id"), Values: []*string{v.VpcId}}},
+		})
+		if err != nil {
+			logrus.WithFields(fields).Warnf("could not describe network interfaces of default VPC: %+v", err)
+			continue
+		}
+		vg.Interfaces[*v.VpcId] = len(descENIs.NetworkInterfaces)
+	}
+
+	descPeerings, err := client.DescribeVpcPeeringConnections(&ec2.DescribeVpcPeeringConnectionsInput{})
+	if err != nil {
+		logrus.WithFields(fields).Warnf("could not describe VPC peering connections: %+v", err)
+		return vg
+	}
+	vg.Peerings = descPeerings.VpcPeeringConnections
+	return vg
+}
+
+// CheckPolicy returns VPCs without flow logs, default VPCs in use,
+// and peering connections with accounts outside of the trusted accounts
+func (av *AV) CheckPolicy(trusted []string) *VV {
+	violations := &VV{Account: av.Account}
+	for _, group := range av.Group {
+		vg := ViolationGroup{Region: group.Region}
+		for _, v := range group.VPCs {
+			if !hasActiveFlowLog(group.FlowLogs[*v.VpcId]) {
+				vg.NoFlowLogs = append(vg.NoFlowLogs, v)
+			}
+			if aws.BoolValue(v.IsDefault) && group.Interfaces[*v.VpcId] > 0 {
+				vg.DefaultVPCs = append(vg.DefaultVPCs, v)
+			}
+		}
+		for _, p := range group.Peerings {
+			if p.Status != nil && aws.StringValue(p.Status.Code) != ec2.VpcPeeringConnectionStateReasonCodeActive {
+				continue
+			}
+			if !oaws.IsTrusted(OwnerID(p.AccepterVpcInfo), trusted) || !oaws.IsTrusted(OwnerID(p.RequesterVpcInfo), trusted) {
+				vg.ExternalPeerings = append(vg.ExternalPeerings, p)
+			}
+		}
+		violations.Group = append(violations.Group, vg)
+	}
+	return violations
+}
+
+func hasActiveFlowLog(fls []*ec2.FlowLog) bool {
+	for _, fl := range fls {
+		if aws.StringValue(fl.FlowLogStatus) == "ACTIVE" {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnerID returns the account number owning one side of a peering connection
+func OwnerID(info *ec2.VpcPeeringConnectionVpcInfo) string {
+	if info == nil {
+		return ""
+	}
+	return aws.StringValue(info.OwnerId)
+}
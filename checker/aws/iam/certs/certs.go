@@ -0,0 +1,118 @@
+package certs
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+)
+
+// Policy represents the certificate and key policy.
+// ExpiryDays is the no. of days before expiry server certificates are reported,
+// and SSHKeyMaxDays is the rotation window of SSH public keys. A zero value disables the rule.
+type Policy struct {
+	ExpiryDays               int
+	SSHKeyMaxDays            int
+	AllowSigningCertificates bool
+}
+
+// AC holds server certificates, SSH public keys and signing certificates per account.
+type AC struct {
+	Account             oaws.Account
+	ServerCertificates  []*iam.ServerCertificateMetadata
+	SSHPublicKeys       []*iam.SSHPublicKeyMetadata
+	SigningCertificates []*iam.SigningCertificate
+}
+
+// CV holds certificate and key violations per account.
+type CV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a certificate or key violating the policy.
+// UserName is empty for server certificates.
+type Violation struct {
+	UserName string
+	ID       string
+	Rule     string
+	Detail   string
+}
+
+// List returns all server certificates of the account, and the SSH public keys
+// and signing certificates of all users found by users.List
+func List(account oaws.Account) *AC {
+	ac := &AC{Account: account}
+	client := oiam.Client(account)
+
+	err := client.ListServerCertificatesPages(&iam.ListServerCertificatesInput{}, func(page *iam.ListServerCertificatesOutput, lastPage bool) bool {
+		ac.ServerCertificates = append(ac.ServerCertificates, page.ServerCertificateMetadataList...)
+		return true
+	})
+	if err != nil {
+		log.Debugf("Could not list server certificates in account [%s]: %+v", account.Name, err)
+	}
+
+	for _, user := range users.List(account).Users {
+		keys, err := client.ListSSHPublicKeys(&iam.ListSSHPublicKeysInput{UserName: aws.String(user.User)})
+		if err != nil {
+			log.Debugf("Could not list SSH public keys for user [%s] in account [%s]: %+v", user.User, account.Name, err)
+		} else {
+			ac.SSHPublicKeys = append(ac.SSHPublicKeys, keys.SSHPublicKeys...)
+		}
+		certs, err := client.ListSigningCertificates(&iam.ListSigningCertificatesInput{UserName: aws.String(user.User)})
+		if err != nil {
+			log.Debugf("Could not list signing certificates for user [%s] in account [%s]: %+v", user.User, account.Name, err)
+		} else {
+			ac.SigningCertificates = append(ac.SigningCertificates, certs.Certificates...)
+		}
+	}
+	return ac
+}
+
+// CheckPolicy returns server certificates that are expired or expiring, active SSH public keys
+// older than the rotation window, and active signing certificates
+func (ac *AC) CheckPolicy(p Policy) *CV {
+	log.Debugf("Checking certificates and keys in account [%s]", ac.Account.Name)
+	violations := &CV{Account: ac.Account}
+	add := func(userName, id, rule, detail string) {
+		violations.Violations = append(violations.Violations, Violation{UserName: userName, ID: id, Rule: rule, Detail: detail})
+	}
+
+	for _, c := range ac.ServerCertificates {
+		if c.Expiration == nil {
+			continue
+		}
+		name, expiry := aws.StringValue(c.ServerCertificateName), *c.Expiration
+		switch {
+		case time.Now().After(expiry):
+			add("", name, "Expired Server Certificate", fmt.Sprintf("expired on %s", expiry.Format("2006-01-02")))
+		case p.ExpiryDays > 0 && time.Until(expiry) < time.Duration(p.ExpiryDays)*24*time.Hour:
+			add("", name, "Expiring Server Certificate", fmt.Sprintf("expires on %s", expiry.Format("2006-01-02")))
+		}
+	}
+
+	for _, k := range ac.SSHPublicKeys {
+		if aws.StringValue(k.Status) != iam.StatusTypeActive || k.UploadDate == nil || p.SSHKeyMaxDays == 0 {
+			continue
+		}
+		if time.Since(*k.UploadDate) > time.Duration(p.SSHKeyMaxDays)*24*time.Hour {
+			add(aws.StringValue(k.UserName), aws.StringValue(k.SSHPublicKeyId), "SSH Public Key Not Rotated",
+				fmt.Sprintf("uploaded on %s", k.UploadDate.Format("2006-01-02")))
+		}
+	}
+
+	if !p.AllowSigningCertificates {
+		for _, c := range ac.SigningCertificates {
+			if aws.StringValue(c.Status) == iam.StatusTypeActive {
+				add(aws.StringValue(c.UserName), aws.StringValue(c.CertificateId), "Active Signing Certificate", "")
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,188 @@
+package credreport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+)
+
+// RootUser is the user name of the root account in the credential report
+const RootUser = "<root_account>"
+
+const (
+	pollInterval = 2 * time.Second
+	maxPolls     = 30
+)
+
+// Report represents the IAM credential report of an account
+type Report struct {
+	Account oaws.Account
+	Entries []*Entry
+}
+
+// Entry represents a single user of the IAM credential report.
+// Dates that are not available (e.g. N/A, no_information) are nil.
+type Entry struct {
+	User                      string
+	Arn                       string
+	UserCreationTime          *time.Time
+	PasswordEnabled           bool
+	PasswordLastUsed          *time.Time
+	PasswordLastChanged       *time.Time
+	PasswordNextRotation      *time.Time
+	MFAActive                 bool
+	AccessKey1Active          bool
+	AccessKey1LastRotated     *time.Time
+	AccessKey1LastUsedDate    *time.Time
+	AccessKey1LastUsedRegion  string
+	AccessKey1LastUsedService string
+	AccessKey2Active          bool
+	AccessKey2LastRotated     *time.Time
+	AccessKey2LastUsedDate    *time.Time
+	AccessKey2LastUsedRegion  string
+	AccessKey2LastUsedService string
+	Cert1Active               bool
+	Cert1LastRotated          *time.Time
+	Cert2Active               bool
+	Cert2LastRotated          *time.Time
+}
+
+// Get generates, retrieves and parses the IAM credential report of an account
+func Get(account oaws.Account) (*Report, error) {
+	client := oiam.Client(account)
+
+	for i := 0; ; i++ {
+		gen, err := client.GenerateCredentialReport(&iam.GenerateCredentialReportInput{})
+		if err != nil {
+			return nil, err
+		}
+		if aws.StringValue(gen.State) == iam.ReportStateTypeComplete {
+			break
+		}
+		if i == maxPolls {
+			return nil, fmt.Errorf("credential report for account [%s] is not ready", account.Name)
+		}
+		log.Debugf("Waiting for credential report in account [%s]", account.Name)
+		time.Sleep(pollInterval)
+	}
+
+	out, err := client.GetCredentialReport(&iam.GetCredentialReportInput{})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Parse(out.Content)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Parsed %d credential report entries in account [%s]", len(entries), account.Name)
+	return &Report{Account: account, Entries: entries}, nil
+}
+
+// Parse parses the CSV content of an IAM credential report
+func Parse(content []byte) ([]*Entry, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("credential report is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+
+	var entries []*Entry
+	for _, record := range records[1:] {
+		r := row{columns: columns, record: record}
+		entries = append(entries, &Entry{
+			User:                      r.str("user"),
+			Arn:                       r.str("arn"),
+			UserCreationTime:          r.date("user_creation_time"),
+			PasswordEnabled:           r.flag("password_enabled"),
+			PasswordLastUsed:          r.date("password_last_used"),
+			PasswordLastChanged:       r.date("password_last_changed"),
+			PasswordNextRotation:      r.date("password_next_rotation"),
+			MFAActive:                 r.flag("mfa_active"),
+			AccessKey1Active:          r.flag("access_key_1_active"),
+			AccessKey1LastRotated:     r.date("access_key_1_last_rotated"),
+			AccessKey1LastUsedDate:    r.date("access_key_1_last_used_date"),
+			AccessKey1LastUsedRegion:  r.str("access_key_1_last_used_region"),
+			AccessKey1LastUsedService: r.str("access_key_1_last_used_service"),
+			AccessKey2Active:          r.flag("access_key_2_active"),
+			AccessKey2LastRotated:     r.date("access_key_2_last_rotated"),
+			AccessKey2LastUsedDate:    r.date("access_key_2_last_used_date"),
+			AccessKey2LastUsedRegion:  r.str("access_key_2_last_used_region"),
+			AccessKey2LastUsedService: r.str("access_key_2_last_used_service"),
+			Cert1Active:               r.flag("cert_1_active"),
+			Cert1LastRotated:          r.date("cert_1_last_rotated"),
+			Cert2Active:               r.flag("cert_2_active"),
+			Cert2LastRotated:          r.date("cert_2_last_rotated"),
+		})
+	}
+	return entries, nil
+}
+
+// Root returns the entry of the root account, if present
+func (r *Report) Root() *Entry {
+	for _, e := range r.Entries {
+		if e.User == RootUser {
+			return e
+		}
+	}
+	return nil
+}
+
+// Users returns the entries of all IAM users, excluding the root account
+func (r *Report) Users() []*Entry {
+	var users []*Entry
+	for _, e := range r.Entries {
+		if e.User != RootUser {
+			users = append(users, e)
+		}
+	}
+	return users
+}
+
+type row struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r row) str(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	switch v := r.record[i]; v {
+	case "N/A", "no_information", "not_supported":
+		return ""
+	default:
+		return v
+	}
+}
+
+func (r row) flag(column string) bool {
+	return r.str(column) == "true"
+}
+
+func (r row) date(column string) *time.Time {
+	v := r.str(column)
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		log.Debugf("Could not parse credential report column [%s] value [%s]: %+v", column, v, err)
+		return nil
+	}
+	return &t
+}
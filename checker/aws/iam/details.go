@@ -0,0 +1,24 @@
+package iam
+
+import (
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+// AuthorizationDetails returns all users, groups, roles and managed policies of an account,
+// along with their policies, aggregated over all pages of GetAccountAuthorizationDetails
+func AuthorizationDetails(account oaws.Account) (*iam.GetAccountAuthorizationDetailsOutput, error) {
+	details := &iam.GetAccountAuthorizationDetailsOutput{}
+	err := Client(account).GetAccountAuthorizationDetailsPages(&iam.GetAccountAuthorizationDetailsInput{},
+		func(page *iam.GetAccountAuthorizationDetailsOutput, lastPage bool) bool {
+			details.UserDetailList = append(details.UserDetailList, page.UserDetailList...)
+			details.GroupDetailList = append(details.GroupDetailList, page.GroupDetailList...)
+			details.RoleDetailList = append(details.RoleDetailList, page.RoleDetailList...)
+			details.Policies = append(details.Policies, page.Policies...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
@@ -0,0 +1,111 @@
+package grants
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+// Policy types
+const (
+	TypeManaged = "managed"
+	TypeUser    = "user"
+	TypeGroup   = "group"
+	TypeRole    = "role"
+)
+
+// AP holds customer-managed and inline policies per account.
+type AP struct {
+	Account  oaws.Account
+	Policies []Policy
+}
+
+// Policy represents a customer-managed policy or an inline policy.
+// Entity is the user, group or role an inline policy is embedded in, or the ARN of a managed policy.
+type Policy struct {
+	Type     string
+	Name     string
+	Entity   string
+	Document *policy.Document
+}
+
+// PV holds policies per account that grant admin-equivalent permissions.
+type PV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a policy granting admin-equivalent permissions
+type Violation struct {
+	Policy   Policy
+	Findings []policy.Finding
+}
+
+// List returns all customer-managed and inline user, group and role policies of the account
+func List(account oaws.Account) *AP {
+	ap := &AP{Account: account}
+	details, err := oiam.AuthorizationDetails(account)
+	if err != nil {
+		log.Debugf("Could not get authorization details in account [%s]: %+v", account.Name, err)
+		return ap
+	}
+
+	for _, p := range details.Policies {
+		if !isCustomerManaged(p) {
+			continue
+		}
+		for _, v := range p.PolicyVersionList {
+			if aws.BoolValue(v.IsDefaultVersion) {
+				ap.add(TypeManaged, aws.StringValue(p.PolicyName), aws.StringValue(p.Arn), aws.StringValue(v.Document))
+			}
+		}
+	}
+	for _, u := range details.UserDetailList {
+		for _, p := range u.UserPolicyList {
+			ap.add(TypeUser, aws.StringValue(p.PolicyName), aws.StringValue(u.UserName), aws.StringValue(p.PolicyDocument))
+		}
+	}
+	for _, g := range details.GroupDetailList {
+		for _, p := range g.GroupPolicyList {
+			ap.add(TypeGroup, aws.StringValue(p.PolicyName), aws.StringValue(g.GroupName), aws.StringValue(p.PolicyDocument))
+		}
+	}
+	for _, r := range details.RoleDetailList {
+		for _, p := range r.RolePolicyList {
+			ap.add(TypeRole, aws.StringValue(p.PolicyName), aws.StringValue(r.RoleName), aws.StringValue(p.PolicyDocument))
+		}
+	}
+	log.Debugf("Listed %d policies in account [%s]", len(ap.Policies), account.Name)
+	return ap
+}
+
+func (ap *AP) add(policyType, name, entity, document string) {
+	doc, err := policy.Parse(document)
+	if err != nil {
+		log.Warnf("Could not parse %s policy [%s] of [%s] in account [%s]: %+v", policyType, name, entity, ap.Account.Name, err)
+		return
+	}
+	ap.Policies = append(ap.Policies, Policy{Type: policyType, Name: name, Entity: entity, Document: doc})
+}
+
+// isCustomerManaged returns true if the managed policy is not an AWS managed policy
+func isCustomerManaged(p *iam.ManagedPolicyDetail) bool {
+	return !strings.Contains(aws.StringValue(p.Arn), ":iam::aws:policy/")
+}
+
+// CheckPolicy returns policies that grant admin-equivalent permissions
+func (ap *AP) CheckPolicy() *PV {
+	log.Debugf("Checking policies for admin-equivalent grants in account [%s]", ap.Account.Name)
+	violations := &PV{Account: ap.Account}
+	for _, p := range ap.Policies {
+		if findings := p.Document.AdminEquivalent(); len(findings) > 0 {
+			violations.Violations = append(violations.Violations, Violation{Policy: p, Findings: findings})
+		}
+	}
+	return violations
+}
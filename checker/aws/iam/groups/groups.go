@@ -0,0 +1,211 @@
+package groups
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+)
+
+// Entity types
+const (
+	TypeUser  = "user"
+	TypeGroup = "group"
+)
+
+// AG holds users and groups, along with their policies and memberships, per account.
+type AG struct {
+	Account oaws.Account
+	Users   []*Entity
+	Groups  []*Entity
+}
+
+// Entity represents a user or a group, its attached managed and inline policies,
+// and its groups (for users) or members (for groups)
+type Entity struct {
+	Type             string
+	Name             string
+	AttachedPolicies []string
+	InlinePolicies   []string
+	Memberships      []string
+
+	// membershipsUnknown is true if memberships could not be retrieved
+	membershipsUnknown bool
+	// policiesUnknown is true if attached or inline policies could not be retrieved
+	policiesUnknown bool
+}
+
+// GV holds policy attachment and group violations per account.
+type GV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a user or group violating the group policy
+type Violation struct {
+	Entity *Entity
+	Rule   string
+	Detail string
+}
+
+// maxConcurrency bounds the no. of users or groups whose policies and memberships are retrieved at once, to avoid throttling
+const maxConcurrency = 5
+
+// List returns all users found by users.List and all groups, along with their policies and memberships
+func List(account oaws.Account) *AG {
+	ag := &AG{Account: account}
+	client := oiam.Client(account)
+
+	au := users.List(account)
+	ec := make(chan *Entity)
+	defer close(ec)
+
+	sem := make(chan struct{}, maxConcurrency)
+	for _, user := range au.Users {
+		go func(name string) {
+			sem <- struct{}{}
+			u := listUser(client, account, name)
+			<-sem
+			ec <- u
+		}(user.User)
+	}
+	for i, user := range au.Users {
+		log.Debugf("[%d] Retrieving policies and groups for user [%s] in account [%s]", i, user.User, account.Name)
+		select {
+		case u := <-ec:
+			ag.Users = append(ag.Users, u)
+		}
+	}
+
+	var groupNames []string
+	err := client.ListGroupsPages(&iam.ListGroupsInput{}, func(page *iam.ListGroupsOutput, lastPage bool) bool {
+		for _, g := range page.Groups {
+			groupNames = append(groupNames, aws.StringValue(g.GroupName))
+		}
+		return true
+	})
+	if err != nil {
+		log.Debugf("Could not list groups in account [%s]: %+v", account.Name, err)
+	}
+	for _, name := range groupNames {
+		go func(name string) {
+			sem <- struct{}{}
+			g := listGroup(client, account, name)
+			<-sem
+			ec <- g
+		}(name)
+	}
+	for i, name := range groupNames {
+		log.Debugf("[%d] Retrieving policies and members for group [%s] in account [%s]", i, name, account.Name)
+		select {
+		case g := <-ec:
+			ag.Groups = append(ag.Groups, g)
+		}
+	}
+	return ag
+}
+
+func listUser(client *iam.IAM, account oaws.Account, name string) *Entity {
+	u := &Entity{Type: TypeUser, Name: name}
+	err := client.ListAttachedUserPoliciesPages(&iam.ListAttachedUserPoliciesInput{UserName: aws.String(name)},
+		func(page *iam.ListAttachedUserPoliciesOutput, lastPage bool) bool {
+			for _, p := range page.AttachedPolicies {
+				u.AttachedPolicies = append(u.AttachedPolicies, aws.StringValue(p.PolicyName))
+			}
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not list attached policies for user [%s] in account [%s]: %+v", name, account.Name, err)
+	}
+	err = client.ListUserPoliciesPages(&iam.ListUserPoliciesInput{UserName: aws.String(name)},
+		func(page *iam.ListUserPoliciesOutput, lastPage bool) bool {
+			u.InlinePolicies = append(u.InlinePolicies, aws.StringValueSlice(page.PolicyNames)...)
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not list inline policies for user [%s] in account [%s]: %+v", name, account.Name, err)
+	}
+	err = client.ListGroupsForUserPages(&iam.ListGroupsForUserInput{UserName: aws.String(name)},
+		func(page *iam.ListGroupsForUserOutput, lastPage bool) bool {
+			for _, g := range page.Groups {
+				u.Memberships = append(u.Memberships, aws.StringValue(g.GroupName))
+			}
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not list groups for user [%s] in account [%s]: %+v", name, account.Name, err)
+		u.membershipsUnknown = true
+	}
+	return u
+}
+
+func listGroup(client *iam.IAM, account oaws.Account, name string) *Entity {
+	g := &Entity{Type: TypeGroup, Name: name}
+	err := client.ListAttachedGroupPoliciesPages(&iam.ListAttachedGroupPoliciesInput{GroupName: aws.String(name)},
+		func(page *iam.ListAttachedGroupPoliciesOutput, lastPage bool) bool {
+			for _, p := range page.AttachedPolicies {
+				g.AttachedPolicies = append(g.AttachedPolicies, aws.StringValue(p.PolicyName))
+			}
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not list attached policies for group [%s] in account [%s]: %+v", name, account.Name, err)
+		g.policiesUnknown = true
+	}
+	err = client.ListGroupPoliciesPages(&iam.ListGroupPoliciesInput{GroupName: aws.String(name)},
+		func(page *iam.ListGroupPoliciesOutput, lastPage bool) bool {
+			g.InlinePolicies = append(g.InlinePolicies, aws.StringValueSlice(page.PolicyNames)...)
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not list inline policies for group [%s] in account [%s]: %+v", name, account.Name, err)
+		g.policiesUnknown = true
+	}
+	err = client.GetGroupPages(&iam.GetGroupInput{GroupName: aws.String(name)},
+		func(page *iam.GetGroupOutput, lastPage bool) bool {
+			for _, u := range page.Users {
+				g.Memberships = append(g.Memberships, aws.StringValue(u.UserName))
+			}
+			return true
+		})
+	if err != nil {
+		log.Debugf("Could not get members of group [%s] in account [%s]: %+v", name, account.Name, err)
+		g.membershipsUnknown = true
+	}
+	return g
+}
+
+// CheckPolicy returns users with attached managed or inline policies, users in no group,
+// groups without policies, and groups without members
+func (ag *AG) CheckPolicy() *GV {
+	log.Debugf("Checking policy attachments and groups in account [%s]", ag.Account.Name)
+	violations := &GV{Account: ag.Account}
+	add := func(e *Entity, rule, detail string) {
+		violations.Violations = append(violations.Violations, Violation{Entity: e, Rule: rule, Detail: detail})
+	}
+
+	for _, u := range ag.Users {
+		if len(u.AttachedPolicies) > 0 {
+			add(u, "User with Attached Policies", fmt.Sprintf("%v", u.AttachedPolicies))
+		}
+		if len(u.InlinePolicies) > 0 {
+			add(u, "User with Inline Policies", fmt.Sprintf("%v", u.InlinePolicies))
+		}
+		if len(u.Memberships) == 0 && !u.membershipsUnknown {
+			add(u, "User in No Group", "")
+		}
+	}
+	for _, g := range ag.Groups {
+		if len(g.AttachedPolicies) == 0 && len(g.InlinePolicies) == 0 && !g.policiesUnknown {
+			add(g, "Group without Policies", "")
+		}
+		if len(g.Memberships) == 0 && !g.membershipsUnknown {
+			add(g, "Group without Members", "")
+		}
+	}
+	return violations
+}
@@ -0,0 +1,205 @@
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/credreport"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+)
+
+// Policy represents the access key policy.
+// A zero value disables the corresponding rule.
+type Policy struct {
+	MaxAgeDays    int
+	MaxUnusedDays int
+}
+
+// AK holds a slice of access keys per account.
+type AK struct {
+	Account oaws.Account
+	Keys    []Key
+}
+
+// Key represents an access key and when it was last used.
+// LastUsed is nil if the key was never used.
+// AccessKeyID is the credential report column (e.g. access_key_1) if the key ID could not be resolved.
+type Key struct {
+	UserName    string
+	AccessKeyID string
+	Active      bool
+	CreateDate  time.Time
+	LastUsed    *time.Time
+}
+
+// KV holds a slice of access key violations per account.
+type KV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a user or an access key violating the access key policy
+type Violation struct {
+	UserName    string
+	AccessKeyID string
+	Rule        string
+	Detail      string
+}
+
+// maxConcurrency bounds the no. of users whose access key IDs are resolved at once, to avoid throttling
+const maxConcurrency = 5
+
+// List enumerates the access keys of all users from the credential report
+func List(account oaws.Account) *AK {
+	ak := &AK{Account: account}
+	client := oiam.Client(account)
+
+	var entries []*credreport.Entry
+	for _, user := range users.List(account).Users {
+		if len(reportKeys(user)) > 0 {
+			entries = append(entries, user)
+		}
+	}
+
+	kc := make(chan []Key)
+	defer close(kc)
+
+	sem := make(chan struct{}, maxConcurrency)
+	for _, user := range entries {
+		go func(user *credreport.Entry) {
+			sem <- struct{}{}
+			keys := resolveKeyIDs(client, account, user.User, reportKeys(user))
+			<-sem
+			kc <- keys
+		}(user)
+	}
+
+	for i, user := range entries {
+		log.Debugf("[%d] Retrieving access keys for user [%s] in account [%s]", i, user.User, account.Name)
+		select {
+		case keys := <-kc:
+			ak.Keys = append(ak.Keys, keys...)
+		}
+	}
+	log.Debugf("Listed %d access keys in account [%s]", len(ak.Keys), account.Name)
+	return ak
+}
+
+// reportKeys returns the access keys of a credential report entry.
+// The credential report has no last rotated date for keys that do not exist.
+func reportKeys(user *credreport.Entry) []Key {
+	var keys []Key
+	for _, k := range []struct {
+		column      string
+		active      bool
+		lastRotated *time.Time
+		lastUsed    *time.Time
+	}{
+		{users.CredentialAccessKey1, user.AccessKey1Active, user.AccessKey1LastRotated, user.AccessKey1LastUsedDate},
+		{users.CredentialAccessKey2, user.AccessKey2Active, user.AccessKey2LastRotated, user.AccessKey2LastUsedDate},
+	} {
+		if k.lastRotated == nil {
+			continue
+		}
+		keys = append(keys, Key{
+			UserName:    user.User,
+			AccessKeyID: k.column,
+			Active:      k.active,
+			CreateDate:  *k.lastRotated,
+			LastUsed:    k.lastUsed,
+		})
+	}
+	return keys
+}
+
+// resolveKeyIDs replaces the credential report columns of the keys with their IDs, matching keys by creation date.
+// IDs are only listed for users with active keys, since inactive keys are not checked.
+// Keys are returned unresolved if their IDs could not be listed.
+func resolveKeyIDs(client *iam.IAM, account oaws.Account, userName string, keys []Key) []Key {
+	active := false
+	for _, key := range keys {
+		active = active || key.Active
+	}
+	if !active {
+		return keys
+	}
+
+	out, err := client.ListAccessKeys(&iam.ListAccessKeysInput{UserName: aws.String(userName)})
+	if err != nil {
+		log.Warnf("Could not list access key IDs for user [%s] in account [%s]: %+v", userName, account.Name, err)
+		return keys
+	}
+	for i, key := range keys {
+		for _, md := range out.AccessKeyMetadata {
+			if aws.TimeValue(md.CreateDate).Truncate(time.Second).Equal(key.CreateDate.Truncate(time.Second)) {
+				keys[i].AccessKeyID = aws.StringValue(md.AccessKeyId)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// CheckPolicy returns active access keys that are not rotated or unused, and users with more than one active access key
+func (ak *AK) CheckPolicy(p Policy) *KV {
+	log.Debugf("Checking access keys in account [%s]", ak.Account.Name)
+	violations := &KV{Account: ak.Account}
+	maxAge := time.Duration(p.MaxAgeDays) * 24 * time.Hour
+	maxUnused := time.Duration(p.MaxUnusedDays) * 24 * time.Hour
+
+	activeKeys := make(map[string][]string)
+	var userNames []string
+	for _, key := range ak.Keys {
+		if !key.Active {
+			continue
+		}
+		if _, ok := activeKeys[key.UserName]; !ok {
+			userNames = append(userNames, key.UserName)
+		}
+		activeKeys[key.UserName] = append(activeKeys[key.UserName], key.AccessKeyID)
+
+		if p.MaxAgeDays > 0 && time.Since(key.CreateDate) > maxAge {
+			violations.Violations = append(violations.Violations, Violation{
+				UserName:    key.UserName,
+				AccessKeyID: key.AccessKeyID,
+				Rule:        "Access Key Not Rotated",
+				Detail:      fmt.Sprintf("created on %s", key.CreateDate.Format("2006-01-02")),
+			})
+		}
+
+		if p.MaxUnusedDays > 0 {
+			if key.LastUsed == nil && time.Since(key.CreateDate) > maxUnused {
+				violations.Violations = append(violations.Violations, Violation{
+					UserName:    key.UserName,
+					AccessKeyID: key.AccessKeyID,
+					Rule:        "Unused Access Key",
+					Detail:      "never used",
+				})
+			}
+			if key.LastUsed != nil && time.Since(*key.LastUsed) > maxUnused {
+				violations.Violations = append(violations.Violations, Violation{
+					UserName:    key.UserName,
+					AccessKeyID: key.AccessKeyID,
+					Rule:        "Unused Access Key",
+					Detail:      fmt.Sprintf("last used on %s", key.LastUsed.Format("2006-01-02")),
+				})
+			}
+		}
+	}
+
+	for _, userName := range userNames {
+		if ids := activeKeys[userName]; len(ids) > 1 {
+			violations.Violations = append(violations.Violations, Violation{
+				UserName: userName,
+				Rule:     "Multiple Active Access Keys",
+				Detail:   fmt.Sprintf("%v", ids),
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,205 @@
+package lastused
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	maxPolls     = 30
+	// maxConcurrency bounds the no. of principals whose last accessed details are retrieved at once, to avoid throttling
+	maxConcurrency = 5
+)
+
+// Principal types
+const (
+	TypeUser = "user"
+	TypeRole = "role"
+)
+
+// AL holds users and roles and the services they last accessed per account.
+type AL struct {
+	Account    oaws.Account
+	Principals []Principal
+}
+
+// Principal represents a user or a role, when it was last used, and the services it is granted.
+// LastUsed is only set for roles, and is nil if the role was never used.
+type Principal struct {
+	Type       string
+	Name       string
+	Arn        string
+	CreateDate *time.Time
+	LastUsed   *time.Time
+	Services   []*iam.ServiceLastAccessed
+	// Err is set if the last accessed services could not be retrieved
+	Err error
+}
+
+// LV holds least-privilege violations per account.
+type LV struct {
+	Account oaws.Account
+	// UnusedRoles holds roles not used in the max no. of days
+	UnusedRoles []Principal
+	// UnusedServices holds users and roles with granted services they did not access in the max no. of days
+	UnusedServices []UnusedServices
+	// Unknown holds users and roles whose last accessed services could not be retrieved
+	Unknown []Principal
+}
+
+// UnusedServices represents the granted services a principal did not access, whose permissions can be removed
+type UnusedServices struct {
+	Principal Principal
+	Services  []string
+}
+
+// List returns all users and roles of the account along with the services they last accessed
+func List(account oaws.Account) *AL {
+	al := &AL{Account: account}
+	client := oiam.Client(account)
+
+	var principals []Principal
+	for _, u := range users.List(account).Users {
+		principals = append(principals, Principal{Type: TypeUser, Name: u.User, Arn: u.Arn, CreateDate: u.UserCreationTime})
+	}
+	err := client.ListRolesPages(&iam.ListRolesInput{}, func(page *iam.ListRolesOutput, lastPage bool) bool {
+		for _, r := range page.Roles {
+			principals = append(principals, Principal{Type: TypeRole, Name: aws.StringValue(r.RoleName), Arn: aws.StringValue(r.Arn), CreateDate: r.CreateDate})
+		}
+		return true
+	})
+	if err != nil {
+		log.Debugf("Could not list roles in account [%s]: %+v", account.Name, err)
+	}
+
+	pc := make(chan Principal)
+	defer close(pc)
+
+	sem := make(chan struct{}, maxConcurrency)
+	for _, p := range principals {
+		go func(p Principal) {
+			sem <- struct{}{}
+			p = lastAccessed(client, account, p)
+			<-sem
+			pc <- p
+		}(p)
+	}
+
+	for i, p := range principals {
+		log.Debugf("[%d] Retrieving last accessed services for %s [%s] in account [%s]", i, p.Type, p.Name, account.Name)
+		select {
+		case v := <-pc:
+			al.Principals = append(al.Principals, v)
+		}
+	}
+	return al
+}
+
+func lastAccessed(client *iam.IAM, account oaws.Account, p Principal) Principal {
+	if p.Type == TypeRole {
+		r, err := client.GetRole(&iam.GetRoleInput{RoleName: aws.String(p.Name)})
+		if err != nil {
+			log.Warnf("Could not get role [%s] in account [%s]: %+v", p.Name, account.Name, err)
+			p.Err = err
+			return p
+		}
+		if r.Role.RoleLastUsed != nil {
+			p.LastUsed = r.Role.RoleLastUsed.LastUsedDate
+		}
+	}
+
+	p.Services, p.Err = servicesLastAccessed(client, p.Arn)
+	if p.Err != nil {
+		log.Warnf("Could not retrieve last accessed details for %s [%s] in account [%s]: %+v", p.Type, p.Name, account.Name, p.Err)
+	}
+	return p
+}
+
+// servicesLastAccessed generates the last accessed details of a principal, waits for the job to complete,
+// and returns the services of all pages
+func servicesLastAccessed(client *iam.IAM, arn string) ([]*iam.ServiceLastAccessed, error) {
+	job, err := client.GenerateServiceLastAccessedDetails(&iam.GenerateServiceLastAccessedDetailsInput{Arn: aws.String(arn)})
+	if err != nil {
+		return nil, err
+	}
+
+	input := &iam.GetServiceLastAccessedDetailsInput{JobId: job.JobId}
+	for i := 0; i < maxPolls; i++ {
+		details, err := client.GetServiceLastAccessedDetails(input)
+		if err != nil {
+			return nil, err
+		}
+		switch aws.StringValue(details.JobStatus) {
+		case iam.JobStatusTypeCompleted:
+			services := details.ServicesLastAccessed
+			for aws.BoolValue(details.IsTruncated) {
+				input.Marker = details.Marker
+				if details, err = client.GetServiceLastAccessedDetails(input); err != nil {
+					return nil, err
+				}
+				services = append(services, details.ServicesLastAccessed...)
+			}
+			return services, nil
+		case iam.JobStatusTypeFailed:
+			if details.Error != nil {
+				return nil, fmt.Errorf("last accessed details job failed: %s", aws.StringValue(details.Error.Message))
+			}
+			return nil, fmt.Errorf("last accessed details job failed")
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil, fmt.Errorf("last accessed details job did not complete after %d polls", maxPolls)
+}
+
+// CheckPolicy returns roles not used in the max no. of days,
+// and users and roles with granted services they did not access in the max no. of days
+func (al *AL) CheckPolicy(maxDays int) *LV {
+	log.Debugf("Checking last used roles and services in account [%s]", al.Account.Name)
+	violations := &LV{Account: al.Account}
+	maxDuration := time.Duration(maxDays) * 24 * time.Hour
+
+	for _, p := range al.Principals {
+		if p.Err != nil {
+			violations.Unknown = append(violations.Unknown, p)
+			continue
+		}
+		if p.Type == TypeRole && isUnused(p.LastUsed, p.CreateDate, maxDuration) {
+			violations.UnusedRoles = append(violations.UnusedRoles, p)
+		}
+
+		var unused []string
+		for _, s := range p.Services {
+			if isUnused(s.LastAuthenticated, p.CreateDate, maxDuration) {
+				unused = append(unused, fmt.Sprintf("%s (%s)", aws.StringValue(s.ServiceNamespace), lastUsed(s.LastAuthenticated)))
+			}
+		}
+		if len(unused) > 0 {
+			violations.UnusedServices = append(violations.UnusedServices, UnusedServices{Principal: p, Services: unused})
+		}
+	}
+	return violations
+}
+
+// isUnused returns true if last used is older than the max duration,
+// or if it was never used and created more than the max duration ago
+func isUnused(last, created *time.Time, maxDuration time.Duration) bool {
+	if last != nil {
+		return time.Since(*last) > maxDuration
+	}
+	return created != nil && time.Since(*created) > maxDuration
+}
+
+func lastUsed(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format("2006-01-02")
+}
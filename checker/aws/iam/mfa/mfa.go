@@ -5,74 +5,168 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
 	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/credreport"
 	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
 )
 
-// MV holds a slice of virtual mfa devices per account.
+// MFA device types
+const (
+	TypeVirtual  = "virtual"
+	TypeHardware = "hardware"
+	TypeU2F      = "u2f"
+)
+
+// Policy represents the MFA policy.
+// MaxDays is the max no. of days console users are allowed without MFA.
+type Policy struct {
+	MaxDays         int
+	RequireHardware bool
+}
+
+// MV holds a slice of console users and their MFA devices per account.
 type MV struct {
-	Account   oaws.Account
-	VMD       []*iam.VirtualMFADevice
-	UserNames []string
+	Account oaws.Account
+	Users   []*User
 }
 
-// MU holds a slice of users per account.
+// User represents a console user and their MFA devices
+type User struct {
+	*credreport.Entry
+	Devices []Device
+	// devicesUnknown is true if MFA devices could not be listed
+	devicesUnknown bool
+}
+
+// Device represents an MFA device
+type Device struct {
+	SerialNumber string
+	Type         string
+	EnableDate   *time.Time
+}
+
+// MU holds a slice of users per account who violate the MFA policy.
 type MU struct {
-	Account oaws.Account
-	Users   []*iam.User
+	Account    oaws.Account
+	Violations []Violation
 }
 
-// List returns all AWS Virtual MFA Devices per account
+// Violation represents a user violating the MFA policy
+type Violation struct {
+	User *User
+	Rule string
+}
+
+// List returns all console users and their MFA devices per account
 func List(account oaws.Account) *MV {
 	mv := &MV{Account: account}
-	iamResp, err := oiam.Client(account).ListVirtualMFADevices(&iam.ListVirtualMFADevicesInput{})
-	if err != nil {
-		log.Debugf("Could not list MFA Devices for account [%s]", account.Name)
-		return mv
+	client := oiam.Client(account)
+
+	uc := make(chan *User)
+	defer close(uc)
+
+	var consoleUsers []*credreport.Entry
+	for _, user := range users.List(account).Users {
+		if user.PasswordEnabled {
+			consoleUsers = append(consoleUsers, user)
+		}
+	}
+
+	for _, user := range consoleUsers {
+		go func(user *credreport.Entry) {
+			uc <- listDevices(client, account, user)
+		}(user)
+	}
+
+	for i, user := range consoleUsers {
+		log.Debugf("[%d] Retrieving MFA devices for user [%s] in account [%s]", i, user.User, account.Name)
+		select {
+		case u := <-uc:
+			mv.Users = append(mv.Users, u)
+		}
 	}
-	log.Debugf("Listed %d Virtual MFA Devices in account [%s]", len(iamResp.VirtualMFADevices), account.Name)
-	mv.VMD = iamResp.VirtualMFADevices
+	log.Debugf("Listed MFA devices of %d console users in account [%s]", len(mv.Users), account.Name)
 	return mv
 }
 
-// CheckPolicy will check AWS MFAs against the MFA Policy
-// and log violations.
-func (mv *MV) CheckPolicy(mfaMaxDays int) *MU {
-	log.Debugf("Checking MFA Policy in Account [%s]", mv.Account.Name)
+func listDevices(client *iam.IAM, account oaws.Account, user *credreport.Entry) *User {
+	u := &User{Entry: user}
+	out, err := client.ListMFADevices(&iam.ListMFADevicesInput{UserName: aws.String(user.User)})
+	if err != nil {
+		log.Warnf("Could not list MFA devices for user [%s] in account [%s]: %+v", user.User, account.Name, err)
+		u.devicesUnknown = true
+		return u
+	}
+	for _, d := range out.MFADevices {
+		serial := aws.StringValue(d.SerialNumber)
+		u.Devices = append(u.Devices, Device{
+			SerialNumber: serial,
+			Type:         deviceType(serial),
+			EnableDate:   d.EnableDate,
+		})
+	}
+	return u
+}
 
-	mc := make(chan MU)
-	defer close(mc)
+// deviceType determines the type of an MFA device from its serial number.
+// Virtual and U2F devices have ARNs as serial numbers, hardware devices have plain serial numbers.
+func deviceType(serial string) string {
+	switch {
+	case strings.Contains(serial, ":u2f/"):
+		return TypeU2F
+	case strings.HasPrefix(serial, "arn:"):
+		return TypeVirtual
+	default:
+		return TypeHardware
+	}
+}
+
+// CheckPolicy will check console users against the MFA Policy
+// and return violations.
+func (mv *MV) CheckPolicy(p Policy) *MU {
+	log.Debugf("Checking MFA Policy in Account [%s]", mv.Account.Name)
 
-	mfaPolicyMaxDays := time.Duration(mfaMaxDays) * 24 * time.Hour
+	mfaPolicyMaxDays := time.Duration(p.MaxDays) * 24 * time.Hour
 	mfaViolations := &MU{Account: mv.Account}
-	mfaMap := make(map[string]*iam.VirtualMFADevice)
-
-	users := users.List(mv.Account)
-
-	for i, mfa := range mv.VMD {
-		log.Debugf("[%d] Checking Virtual MFA Device [%+v] in Account [%s]", i, mfa, mv.Account.Name)
-		// some mfa records contain just a serial number, but no User info (indicating user may have had MFA enabled and later disabled it).
-		mfaUser := strings.Split(*mfa.SerialNumber, "/")[1]
-		mfaMap[mfaUser] = mfa
-		if mfa.User == nil {
-			log.Debugf("User %s may have disabled MFA!", mfaUser)
-			for _, user := range users.Users {
-				if mfaUser == *user.UserName {
-					mfaViolations.Users = append(mfaViolations.Users, user)
-				}
+
+	for _, user := range mv.Users {
+		// fall back to the credential report if devices could not be listed, which cannot tell device types apart
+		if user.devicesUnknown {
+			if user.MFAActive {
+				continue
+			}
+		} else if len(user.Devices) > 0 {
+			if p.RequireHardware && !user.hasHardware() {
+				log.Debugf("User %+v only has virtual MFA devices", user.User)
+				mfaViolations.Violations = append(mfaViolations.Violations, Violation{User: user, Rule: "Virtual MFA Only"})
 			}
+			continue
+		}
+		if user.UserCreationTime != nil && time.Since(*user.UserCreationTime) > mfaPolicyMaxDays {
+			log.Debugf("User %+v has not enabled MFA since %+v", user.User, *user.UserCreationTime)
+			mfaViolations.Violations = append(mfaViolations.Violations, Violation{User: user, Rule: "Disabled MFA"})
 		}
 	}
+	return mfaViolations
+}
+
+// DeviceTypes returns the types of the MFA devices of the user
+func (u *User) DeviceTypes() []string {
+	var types []string
+	for _, d := range u.Devices {
+		types = append(types, d.Type)
+	}
+	return types
+}
 
-	for _, user := range users.Users {
-		if _, ok := mfaMap[*user.UserName]; !ok {
-			if user.PasswordLastUsed != nil && time.Since(*user.CreateDate) > mfaPolicyMaxDays {
-				log.Debugf("User %+v has not enabled MFA since %+v", *user.UserName, *user.CreateDate)
-				mfaViolations.Users = append(mfaViolations.Users, user)
-			}
+func (u *User) hasHardware() bool {
+	for _, d := range u.Devices {
+		if d.Type != TypeVirtual {
+			return true
 		}
 	}
-	return mfaViolations
+	return false
 }
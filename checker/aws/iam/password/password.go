@@ -0,0 +1,112 @@
+package password
+
+import (
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+)
+
+// Policy represents the desired account password policy.
+// A zero value does not require the corresponding setting.
+type Policy struct {
+	MinimumLength    int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireNumbers   bool
+	RequireSymbols   bool
+	MaxAgeDays       int
+	ReusePrevention  int
+	HardExpiry       bool
+}
+
+// AP holds the password policy per account.
+// PasswordPolicy is nil if the account has no password policy.
+type AP struct {
+	Account        oaws.Account
+	PasswordPolicy *iam.PasswordPolicy
+	// Err is set if the password policy could not be retrieved
+	Err error
+}
+
+// PV holds password policy deviations per account.
+// Missing is true if the account has no password policy at all.
+// Err is set if the password policy could not be retrieved, in which case it was not checked.
+type PV struct {
+	Account    oaws.Account
+	Missing    bool
+	Deviations []Deviation
+	Err        error
+}
+
+// Deviation represents a password policy setting that deviates from the desired policy
+type Deviation struct {
+	Setting string
+	Want    string
+	Got     string
+}
+
+// List returns the password policy of the account
+func List(account oaws.Account) *AP {
+	ap := &AP{Account: account}
+	out, err := oiam.Client(account).GetAccountPasswordPolicy(&iam.GetAccountPasswordPolicyInput{})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			log.Debugf("Account [%s] has no password policy", account.Name)
+			return ap
+		}
+		log.Debugf("Could not get password policy in account [%s]: %+v", account.Name, err)
+		ap.Err = err
+		return ap
+	}
+	ap.PasswordPolicy = out.PasswordPolicy
+	return ap
+}
+
+// CheckPolicy compares the password policy of the account against the desired policy
+func (ap *AP) CheckPolicy(p Policy) *PV {
+	log.Debugf("Checking password policy in account [%s]", ap.Account.Name)
+	violations := &PV{Account: ap.Account}
+	if ap.Err != nil {
+		violations.Err = ap.Err
+		return violations
+	}
+	if ap.PasswordPolicy == nil {
+		violations.Missing = true
+		return violations
+	}
+
+	pp := ap.PasswordPolicy
+	add := func(setting, want, got string) {
+		violations.Deviations = append(violations.Deviations, Deviation{Setting: setting, Want: want, Got: got})
+	}
+	requireBool := func(setting string, want bool, got *bool) {
+		if want && !aws.BoolValue(got) {
+			add(setting, "true", "false")
+		}
+	}
+
+	if got := aws.Int64Value(pp.MinimumPasswordLength); p.MinimumLength > 0 && got < int64(p.MinimumLength) {
+		add("MinimumPasswordLength", ">= "+strconv.Itoa(p.MinimumLength), strconv.FormatInt(got, 10))
+	}
+	requireBool("RequireUppercaseCharacters", p.RequireUppercase, pp.RequireUppercaseCharacters)
+	requireBool("RequireLowercaseCharacters", p.RequireLowercase, pp.RequireLowercaseCharacters)
+	requireBool("RequireNumbers", p.RequireNumbers, pp.RequireNumbers)
+	requireBool("RequireSymbols", p.RequireSymbols, pp.RequireSymbols)
+	if p.MaxAgeDays > 0 {
+		if !aws.BoolValue(pp.ExpirePasswords) {
+			add("MaxPasswordAge", "<= "+strconv.Itoa(p.MaxAgeDays), "never")
+		} else if got := aws.Int64Value(pp.MaxPasswordAge); got > int64(p.MaxAgeDays) {
+			add("MaxPasswordAge", "<= "+strconv.Itoa(p.MaxAgeDays), strconv.FormatInt(got, 10))
+		}
+	}
+	if got := aws.Int64Value(pp.PasswordReusePrevention); p.ReusePrevention > 0 && got < int64(p.ReusePrevention) {
+		add("PasswordReusePrevention", ">= "+strconv.Itoa(p.ReusePrevention), strconv.FormatInt(got, 10))
+	}
+	requireBool("HardExpiry", p.HardExpiry, pp.HardExpiry)
+	return violations
+}
@@ -0,0 +1,205 @@
+package policy
+
+import (
+	"strings"
+)
+
+// PrivilegedActions are IAM actions that grant admin or allow escalating to admin.
+// Wildcards in policy actions are expanded against this list.
+var PrivilegedActions = []string{
+	"iam:AddUserToGroup",
+	"iam:AttachGroupPolicy",
+	"iam:AttachRolePolicy",
+	"iam:AttachUserPolicy",
+	"iam:CreateAccessKey",
+	"iam:CreateLoginProfile",
+	"iam:CreatePolicyVersion",
+	"iam:PassRole",
+	"iam:PutGroupPolicy",
+	"iam:PutRolePolicy",
+	"iam:PutUserPolicy",
+	"iam:SetDefaultPolicyVersion",
+	"iam:UpdateAssumeRolePolicy",
+	"iam:UpdateLoginProfile",
+	"sts:AssumeRole",
+}
+
+// Expand returns the privileged actions matched by an action pattern
+func Expand(pattern string) []string {
+	var actions []string
+	for _, action := range PrivilegedActions {
+		if Match(pattern, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// Finding represents an admin-equivalent grant of a statement.
+// Partial is set if the statement excludes resources with wildcard NotResource patterns (e.g. arn:aws:s3:::*),
+// in which case the grant does not apply to all resources.
+type Finding struct {
+	Sid     string
+	Reason  string
+	Partial bool
+}
+
+// AdminEquivalent returns the admin-equivalent grants of all statements of the document
+func (d *Document) AdminEquivalent() []Finding {
+	var findings []Finding
+	for _, stmt := range d.Statement {
+		for _, reason := range stmt.AdminEquivalent() {
+			findings = append(findings, Finding{Sid: stmt.Sid, Reason: reason, Partial: !stmt.allResources()})
+		}
+	}
+	return findings
+}
+
+// AdminEquivalent returns the reasons a statement is admin-equivalent.
+// Only Allow statements granting access to all resources, or to all resources not excluded by NotResource, are considered.
+func (s Statement) AdminEquivalent() []string {
+	if s.Effect != "Allow" || !s.allResources() && len(s.NotResource) == 0 {
+		return nil
+	}
+
+	reasons := s.adminEquivalent()
+	if len(s.NotResource) > 0 {
+		for i := range reasons {
+			reasons[i] += " (NotResource " + strings.Join(s.NotResource, ",") + ")"
+		}
+	}
+	return reasons
+}
+
+func (s Statement) adminEquivalent() []string {
+	if len(s.NotAction) > 0 {
+		return s.notActionAdminEquivalent()
+	}
+
+	// a statement granting all actions or all IAM actions makes the remaining checks redundant
+	for _, perm := range []string{"*:*", "iam:*"} {
+		if s.Action.Contains(perm) {
+			return []string{perm}
+		}
+	}
+
+	var reasons []string
+	seen := make(map[string]bool)
+	for _, pattern := range s.Action {
+		for _, action := range Expand(pattern) {
+			if !seen[action] {
+				seen[action] = true
+				reasons = append(reasons, action+" on *")
+			}
+		}
+	}
+	return reasons
+}
+
+// notActionAdminEquivalent returns the privileged actions a NotAction statement does not exclude.
+// It reports iam:* if no NotAction pattern can match an IAM action.
+func (s Statement) notActionAdminEquivalent() []string {
+	via := " (NotAction " + strings.Join(s.NotAction, ",") + ")"
+	excludesIAM := false
+	for _, pattern := range s.NotAction {
+		if Match(service(pattern), "iam") {
+			excludesIAM = true
+			break
+		}
+	}
+	if !excludesIAM {
+		return []string{"iam:*" + via}
+	}
+
+	var reasons []string
+	for _, action := range PrivilegedActions {
+		if !s.NotAction.Contains(action) {
+			reasons = append(reasons, action+" on *"+via)
+		}
+	}
+	return reasons
+}
+
+// service returns the service prefix of an action pattern, or the whole pattern if it has none (e.g. "*")
+func service(pattern string) string {
+	if i := strings.Index(pattern, ":"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// allResources returns true if the statement applies to all resources.
+// A NotResource statement does if it only excludes specific resources, i.e. none of its patterns has a wildcard.
+func (s Statement) allResources() bool {
+	if len(s.NotResource) == 0 {
+		return s.Resource.Contains("*")
+	}
+	for _, pattern := range s.NotResource {
+		if strings.ContainsAny(pattern, "*?") {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows returns true if the documents allow the action on the resource.
+// An unconditional Deny takes precedence over any Allow. Allow conditions are assumed to be satisfied,
+// and conditional Denies (e.g. force-MFA policies) are ignored, so that no allowed access is missed.
+func Allows(docs []*Document, action, resource string) bool {
+	allowed := false
+	for _, d := range docs {
+		for _, stmt := range d.Statement {
+			if !stmt.matches(action, resource) {
+				continue
+			}
+			switch stmt.Effect {
+			case "Deny":
+				if len(stmt.Condition) == 0 {
+					return false
+				}
+			case "Allow":
+				allowed = true
+			}
+		}
+	}
+	return allowed
+}
+
+func (s Statement) matches(action, resource string) bool {
+	switch {
+	case len(s.Action) > 0 && !s.Action.Contains(action):
+		return false
+	case len(s.NotAction) > 0 && s.NotAction.Contains(action):
+		return false
+	case len(s.Resource) > 0 && !s.Resource.Contains(resource):
+		return false
+	case len(s.NotResource) > 0 && s.NotResource.Contains(resource):
+		return false
+	}
+	return true
+}
+
+// AllowsAny returns true if the documents allow the action on at least one resource.
+// Only an unconditional Deny on all resources takes precedence. Allow conditions are assumed to be satisfied.
+func AllowsAny(docs []*Document, action string) bool {
+	allowed := false
+	for _, d := range docs {
+		for _, stmt := range d.Statement {
+			switch {
+			case len(stmt.Action) > 0 && !stmt.Action.Contains(action):
+				continue
+			case len(stmt.NotAction) > 0 && stmt.NotAction.Contains(action):
+				continue
+			}
+			switch stmt.Effect {
+			case "Deny":
+				if stmt.allResources() && len(stmt.NotResource) == 0 && len(stmt.Condition) == 0 {
+					return false
+				}
+			case "Allow":
+				allowed = true
+			}
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,281 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Document represents an IAM policy document
+type Document struct {
+	Version   string     `json:"Version"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statement represents a single statement of an IAM policy document.
+// String-or-array fields are normalized to slices.
+type Statement struct {
+	Sid          string                      `json:"Sid"`
+	Effect       string                      `json:"Effect"`
+	Principal    Principal                   `json:"Principal"`
+	NotPrincipal Principal                   `json:"NotPrincipal"`
+	Action       Value                       `json:"Action"`
+	NotAction    Value                       `json:"NotAction"`
+	Resource     Value                       `json:"Resource"`
+	NotResource  Value                       `json:"NotResource"`
+	Condition    map[string]map[string]Value `json:"Condition"`
+}
+
+// Statements is a list of statements, which may be a single object in a policy document
+type Statements []Statement
+
+// UnmarshalJSON decodes a single statement or an array of statements
+func (s *Statements) UnmarshalJSON(b []byte) error {
+	var stmt Statement
+	if err := json.Unmarshal(b, &stmt); err == nil {
+		*s = Statements{stmt}
+		return nil
+	}
+	var stmts []Statement
+	if err := json.Unmarshal(b, &stmts); err != nil {
+		return err
+	}
+	*s = stmts
+	return nil
+}
+
+// Value is a policy element that may be a single string or an array of strings.
+// Condition values may also be JSON booleans or numbers, which are converted to strings.
+type Value []string
+
+// UnmarshalJSON decodes a single scalar or an array of scalars
+func (v *Value) UnmarshalJSON(b []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		items = []interface{}{raw}
+	}
+	values := make(Value, 0, len(items))
+	for _, item := range items {
+		s, err := scalar(item)
+		if err != nil {
+			return err
+		}
+		values = append(values, s)
+	}
+	*v = values
+	return nil
+}
+
+func scalar(item interface{}) (string, error) {
+	switch i := item.(type) {
+	case string:
+		return i, nil
+	case bool:
+		return strconv.FormatBool(i), nil
+	case float64:
+		return strconv.FormatFloat(i, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("policy: unsupported value %v of type %T", item, item)
+}
+
+// Principal maps a principal type (e.g. AWS, Service, Federated) to its values.
+// The "*" principal is normalized to {"AWS": ["*"]}.
+type Principal map[string]Value
+
+// UnmarshalJSON decodes the "*" principal or a map of principal types
+func (p *Principal) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*p = Principal{"AWS": Value{str}}
+		return nil
+	}
+	var m map[string]Value
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	*p = m
+	return nil
+}
+
+// Parse decodes a policy document, which the IAM API returns URL-encoded
+func Parse(doc string) (*Document, error) {
+	if !strings.HasPrefix(strings.TrimSpace(doc), "{") {
+		decoded, err := url.PathUnescape(doc)
+		if err != nil {
+			return nil, err
+		}
+		doc = decoded
+	}
+	var d Document
+	if err := json.Unmarshal([]byte(doc), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Match returns true if the IAM pattern, which may contain * and ? wildcards,
+// matches s. Matching is case-insensitive, like action matching in IAM.
+func Match(pattern, s string) bool {
+	return match(strings.ToLower(pattern), strings.ToLower(s))
+}
+
+func match(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := 0; i <= len(s); i++ {
+				if match(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// Broad returns the permissions from perms that the statement grants on all resources.
+// Permissions may be wildcards themselves (e.g. iam:*), in which case the statement
+// must grant the whole wildcard. A NotAction statement grants every permission it does not exclude.
+func (s Statement) Broad(perms []string) []string {
+	if s.Effect != "Allow" || !s.Resource.Contains("*") {
+		return nil
+	}
+	var broad []string
+	for _, perm := range perms {
+		if len(s.NotAction) > 0 {
+			if !s.excludes(perm) {
+				broad = append(broad, perm)
+			}
+			continue
+		}
+		for _, action := range s.Action {
+			if Match(action, perm) {
+				broad = append(broad, perm)
+				break
+			}
+		}
+	}
+	return broad
+}
+
+// Broad returns the permissions from perms that the documents grant on all resources,
+// less those an unconditional Deny statement takes away on all resources
+func Broad(docs []*Document, perms []string) []string {
+	granted := make(map[string]bool)
+	denied := make(map[string]bool)
+	for _, d := range docs {
+		for _, stmt := range d.Statement {
+			for _, perm := range stmt.Broad(perms) {
+				granted[perm] = true
+			}
+			for _, perm := range perms {
+				if stmt.denies(perm) {
+					denied[perm] = true
+				}
+			}
+		}
+	}
+	var broad []string
+	for _, perm := range perms {
+		if granted[perm] && !denied[perm] {
+			broad = append(broad, perm)
+		}
+	}
+	return broad
+}
+
+// denies returns true if the statement is an unconditional Deny on all resources covering the permission,
+// or all actions of a service within a wildcard permission (e.g. iam:* within *:*)
+func (s Statement) denies(perm string) bool {
+	if s.Effect != "Deny" || len(s.Condition) > 0 || !s.Resource.Contains("*") {
+		return false
+	}
+	if len(s.NotAction) > 0 {
+		// a NotAction Deny denies all actions of the services it does not exclude
+		return !s.excludes(perm) || (service(perm) == "*" && !s.NotAction.Contains("*"))
+	}
+	for _, pattern := range s.Action {
+		if Match(pattern, perm) || (strings.HasSuffix(pattern, ":*") && Match(perm, pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes returns true if any NotAction pattern of the statement excludes part of the permission.
+// A wildcard permission (e.g. s3:*) is partly excluded by any pattern of the same service.
+func (s Statement) excludes(perm string) bool {
+	if !strings.Contains(perm, "*") {
+		return s.NotAction.Contains(perm)
+	}
+	for _, pattern := range s.NotAction {
+		if Match(service(pattern), service(perm)) || Match(service(perm), service(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains returns true if any pattern in v matches s
+func (v Value) Contains(s string) bool {
+	for _, pattern := range v {
+		if Match(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasConditionKey returns true if any condition operator of the statement tests the key.
+// Condition keys are case-insensitive.
+func (s Statement) HasConditionKey(key string) bool {
+	for _, conditions := range s.Condition {
+		for k := range conditions {
+			if strings.EqualFold(k, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AccountID returns the account number of an AWS principal, which may be an account number or an ARN.
+// It returns an empty string if the principal is not tied to an account (e.g. "*").
+func AccountID(principal string) string {
+	if isAccountNumber(principal) {
+		return principal
+	}
+	parts := strings.Split(principal, ":")
+	if len(parts) >= 6 && parts[0] == "arn" && isAccountNumber(parts[4]) {
+		return parts[4]
+	}
+	return ""
+}
+
+func isAccountNumber(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,360 @@
+package policy
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+const (
+	administratorAccess = `{
+  "Version": "2012-10-17",
+  "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]
+}`
+
+	powerUserAccess = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "NotAction": ["iam:*", "organizations:*", "account:*"],
+      "Resource": "*"
+    },
+    {
+      "Effect": "Allow",
+      "Action": [
+        "iam:CreateServiceLinkedRole",
+        "iam:DeleteServiceLinkedRole",
+        "iam:ListRoles",
+        "organizations:DescribeOrganization",
+        "account:ListRegions"
+      ],
+      "Resource": "*"
+    }
+  ]
+}`
+
+	// forceMFA is the AWS-recommended policy denying most actions to users without MFA
+	forceMFA = `{
+  "Version": "2012-10-17",
+  "Statement": [{
+    "Sid": "DenyAllExceptListedIfNoMFA",
+    "Effect": "Deny",
+    "NotAction": [
+      "iam:CreateVirtualMFADevice",
+      "iam:EnableMFADevice",
+      "iam:GetUser",
+      "iam:ListMFADevices",
+      "iam:ListVirtualMFADevices",
+      "iam:ResyncMFADevice",
+      "sts:GetSessionToken"
+    ],
+    "Resource": "*",
+    "Condition": {"BoolIfExists": {"aws:MultiFactorAuthPresent": "false"}}
+  }]
+}`
+
+	secureTransportBucketPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": {
+    "Sid": "DenyInsecureTransport",
+    "Effect": "Deny",
+    "Principal": "*",
+    "Action": "s3:*",
+    "Resource": ["arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"],
+    "Condition": {"Bool": {"aws:SecureTransport": false}}
+  }
+}`
+
+	crossAccountBucketPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [{
+    "Effect": "Allow",
+    "Principal": {"AWS": ["arn:aws:iam::111122223333:root", "444455556666"], "Service": "logging.s3.amazonaws.com"},
+    "Action": ["s3:GetObject", "s3:PutObject"],
+    "Resource": "arn:aws:s3:::bucket/*",
+    "Condition": {"NumericLessThan": {"aws:MultiFactorAuthAge": 3600}, "StringEquals": {"aws:SourceAccount": ["111122223333", "444455556666"]}}
+  }]
+}`
+)
+
+func mustParse(t *testing.T, doc string) *Document {
+	t.Helper()
+	d, err := Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want Statements
+	}{
+		{
+			name: "single statement and scalar fields",
+			doc:  administratorAccess,
+			want: Statements{{Effect: "Allow", Action: Value{"*"}, Resource: Value{"*"}}},
+		},
+		{
+			name: "URL-encoded document",
+			doc:  url.PathEscape(administratorAccess),
+			want: Statements{{Effect: "Allow", Action: Value{"*"}, Resource: Value{"*"}}},
+		},
+		{
+			name: "wildcard principal and boolean condition",
+			doc:  secureTransportBucketPolicy,
+			want: Statements{{
+				Sid:       "DenyInsecureTransport",
+				Effect:    "Deny",
+				Principal: Principal{"AWS": Value{"*"}},
+				Action:    Value{"s3:*"},
+				Resource:  Value{"arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"},
+				Condition: map[string]map[string]Value{"Bool": {"aws:SecureTransport": Value{"false"}}},
+			}},
+		},
+		{
+			name: "principal map, numeric condition and condition arrays",
+			doc:  crossAccountBucketPolicy,
+			want: Statements{{
+				Effect: "Allow",
+				Principal: Principal{
+					"AWS":     Value{"arn:aws:iam::111122223333:root", "444455556666"},
+					"Service": Value{"logging.s3.amazonaws.com"},
+				},
+				Action:   Value{"s3:GetObject", "s3:PutObject"},
+				Resource: Value{"arn:aws:s3:::bucket/*"},
+				Condition: map[string]map[string]Value{
+					"NumericLessThan": {"aws:MultiFactorAuthAge": Value{"3600"}},
+					"StringEquals":    {"aws:SourceAccount": Value{"111122223333", "444455556666"}},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustParse(t, tt.doc).Statement; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"Statement": {"Effect": "Allow", "Action": {"s3": "GetObject"}}}`,
+		`{"Statement": [{"Effect": "Allow", "Condition": {"Null": {"aws:TokenIssueTime": [null]}}}]}`,
+		`not a policy`,
+	} {
+		if _, err := Parse(doc); err == nil {
+			t.Errorf("Parse(%q) expected error", doc)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "s3:GetObject", true},
+		{"s3:*", "s3:GetObject", true},
+		{"S3:get*", "s3:GetObject", true},
+		{"s3:Get?bject", "s3:GetObject", true},
+		{"s3:Get*", "s3:PutObject", false},
+		{"iam:PassRole", "iam:PassRoles", false},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket/key", true},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAccountID(t *testing.T) {
+	tests := map[string]string{
+		"111122223333":                        "111122223333",
+		"arn:aws:iam::111122223333:root":      "111122223333",
+		"arn:aws:iam::111122223333:role/role": "111122223333",
+		"*":                                   "",
+		"arn:aws:iam::*:root":                 "",
+		"AROAEXAMPLEUNIQUEID":                 "",
+		"arn:aws:s3:::bucket":                 "",
+	}
+	for principal, want := range tests {
+		if got := AccountID(principal); got != want {
+			t.Errorf("AccountID(%q) = %q, want %q", principal, got, want)
+		}
+	}
+}
+
+func TestBroad(t *testing.T) {
+	perms := []string{"*:*", "iam:*", "s3:*"}
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"AdministratorAccess", administratorAccess, []string{"*:*", "iam:*", "s3:*"}},
+		{"PowerUserAccess", powerUserAccess, []string{"s3:*"}},
+		{"NotAction excluding some S3 actions", `{"Statement": {"Effect": "Allow", "NotAction": "s3:Delete*", "Resource": "*"}}`, []string{"iam:*"}},
+		{"scoped resource", `{"Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "arn:aws:s3:::bucket/*"}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, stmt := range mustParse(t, tt.doc).Statement {
+				got = append(got, stmt.Broad(perms)...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Broad() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroadDocuments(t *testing.T) {
+	perms := []string{"*:*", "iam:*", "s3:*"}
+	tests := []struct {
+		name string
+		docs []string
+		want []string
+	}{
+		{"no deny", []string{administratorAccess}, []string{"*:*", "iam:*", "s3:*"}},
+		{"deny IAM", []string{administratorAccess, `{"Statement": {"Effect": "Deny", "Action": "iam:*", "Resource": "*"}}`}, []string{"s3:*"}},
+		{"deny single action", []string{administratorAccess, `{"Statement": {"Effect": "Deny", "Action": "s3:DeleteBucket", "Resource": "*"}}`}, []string{"*:*", "iam:*", "s3:*"}},
+		{"deny all but IAM", []string{administratorAccess, `{"Statement": {"Effect": "Deny", "NotAction": "iam:*", "Resource": "*"}}`}, []string{"iam:*"}},
+		{"conditional deny", []string{administratorAccess, forceMFA}, []string{"*:*", "iam:*", "s3:*"}},
+		{"deny on a single resource", []string{administratorAccess, `{"Statement": {"Effect": "Deny", "Action": "*", "Resource": "arn:aws:s3:::bucket"}}`}, []string{"*:*", "iam:*", "s3:*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs []*Document
+			for _, doc := range tt.docs {
+				docs = append(docs, mustParse(t, doc))
+			}
+			if got := Broad(docs, perms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Broad() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowsAny(t *testing.T) {
+	docs := []*Document{mustParse(t, administratorAccess), mustParse(t, forceMFA)}
+	if !AllowsAny(docs, "iam:PassRole") {
+		t.Errorf("AllowsAny() = false with a conditional deny, want true")
+	}
+	docs = append(docs, mustParse(t, `{"Statement": {"Effect": "Deny", "Action": "iam:PassRole", "Resource": "*"}}`))
+	if AllowsAny(docs, "iam:PassRole") {
+		t.Errorf("AllowsAny() = true with an unconditional deny, want false")
+	}
+}
+
+func TestAdminEquivalent(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    []string
+		partial bool
+	}{
+		{
+			name: "AdministratorAccess",
+			doc:  administratorAccess,
+			want: []string{"*:*"},
+		},
+		{
+			name: "PowerUserAccess excludes IAM",
+			doc:  powerUserAccess,
+			want: []string{"sts:AssumeRole on * (NotAction iam:*,organizations:*,account:*)"},
+		},
+		{
+			name: "NotAction not excluding IAM",
+			doc:  `{"Statement": {"Effect": "Allow", "NotAction": "s3:*", "Resource": "*"}}`,
+			want: []string{"iam:* (NotAction s3:*)"},
+		},
+		{
+			name: "PassRole on all resources",
+			doc:  `{"Statement": {"Effect": "Allow", "Action": ["iam:PassRole", "ec2:RunInstances"], "Resource": "*"}}`,
+			want: []string{"iam:PassRole on *"},
+		},
+		{
+			name: "PassRole on a single role",
+			doc:  `{"Statement": {"Effect": "Allow", "Action": "iam:PassRole", "Resource": "arn:aws:iam::111122223333:role/app"}}`,
+		},
+		{
+			name: "NotResource excluding a specific role",
+			doc:  `{"Statement": {"Effect": "Allow", "Action": "iam:*", "NotResource": "arn:aws:iam::111122223333:role/breakglass"}}`,
+			want: []string{"iam:* (NotResource arn:aws:iam::111122223333:role/breakglass)"},
+		},
+		{
+			name:    "NotResource excluding all S3 resources",
+			doc:     `{"Statement": {"Effect": "Allow", "Action": "*", "NotResource": "arn:aws:s3:::*"}}`,
+			want:    []string{"*:* (NotResource arn:aws:s3:::*)"},
+			partial: true,
+		},
+		{
+			name: "Deny",
+			doc:  `{"Statement": {"Effect": "Deny", "Action": "*", "Resource": "*"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range mustParse(t, tt.doc).AdminEquivalent() {
+				got = append(got, f.Reason)
+				if f.Partial != tt.partial {
+					t.Errorf("AdminEquivalent() Partial = %v for %q, want %v", f.Partial, f.Reason, tt.partial)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AdminEquivalent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		docs     []string
+		action   string
+		resource string
+		want     bool
+	}{
+		{"admin", []string{administratorAccess}, "*:*", "*", true},
+		{"power user cannot pass roles", []string{powerUserAccess}, "iam:PassRole", "*", false},
+		{"power user can list roles", []string{powerUserAccess}, "iam:ListRoles", "*", true},
+		{"power user can read objects", []string{powerUserAccess}, "s3:GetObject", "arn:aws:s3:::bucket/key", true},
+		{
+			name:     "unconditional deny takes precedence",
+			docs:     []string{administratorAccess, `{"Statement": {"Effect": "Deny", "Action": "iam:*", "Resource": "*"}}`},
+			action:   "iam:PassRole",
+			resource: "*",
+			want:     false,
+		},
+		{"conditional deny is ignored", []string{administratorAccess, forceMFA}, "*:*", "*", true},
+		{
+			name:     "resource mismatch",
+			docs:     []string{`{"Statement": {"Effect": "Allow", "Action": "iam:PassRole", "Resource": "arn:aws:iam::111122223333:role/app"}}`},
+			action:   "iam:PassRole",
+			resource: "*",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs []*Document
+			for _, doc := range tt.docs {
+				docs = append(docs, mustParse(t, doc))
+			}
+			if got := Allows(docs, tt.action, tt.resource); got != tt.want {
+				t.Errorf("Allows(%q, %q) = %v, want %v", tt.action, tt.resource, got, tt.want)
+			}
+		})
+	}
+}
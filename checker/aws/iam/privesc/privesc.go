@@ -0,0 +1,335 @@
+package privesc
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+// Admin is the node of the graph every escalation path ends at
+const Admin = "admin"
+
+// Principal types
+const (
+	TypeUser  = "user"
+	TypeGroup = "group"
+	TypeRole  = "role"
+)
+
+// services that can be passed a role and run code with it, and the actions needed to do so
+var passRoleServices = map[string][]string{
+	"lambda.amazonaws.com":         {"lambda:CreateFunction", "lambda:InvokeFunction"},
+	"ec2.amazonaws.com":            {"ec2:RunInstances"},
+	"cloudformation.amazonaws.com": {"cloudformation:CreateStack"},
+	"glue.amazonaws.com":           {"glue:CreateDevEndpoint"},
+}
+
+// AG holds the principal/permission graph per account.
+type AG struct {
+	Account    oaws.Account
+	Principals []*Principal
+}
+
+// Principal represents a user, group or role and the policy documents that apply to it.
+// Documents of a user include the documents of its groups.
+type Principal struct {
+	Type      string
+	Name      string
+	Arn       string
+	Groups    []string
+	Managed   []string
+	Documents []*policy.Document
+	Trust     *policy.Document
+}
+
+// Node returns the name of the principal in the graph
+func (p *Principal) Node() string {
+	return p.Type + ":" + p.Name
+}
+
+// Edge represents a step from one principal to another, or to Admin
+type Edge struct {
+	To  string
+	Via string
+}
+
+// PV holds principals per account that can escalate to admin.
+type PV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a principal that can escalate to admin, and the shortest path to admin
+type Violation struct {
+	Principal *Principal
+	Path      []Edge
+}
+
+// List builds the principal/permission graph of the account from all IAM policies
+func List(account oaws.Account) *AG {
+	ag := &AG{Account: account}
+	details, err := oiam.AuthorizationDetails(account)
+	if err != nil {
+		log.Debugf("Could not get authorization details in account [%s]: %+v", account.Name, err)
+		return ag
+	}
+
+	managed := make(map[string]*policy.Document)
+	for _, p := range details.Policies {
+		for _, v := range p.PolicyVersionList {
+			if !aws.BoolValue(v.IsDefaultVersion) {
+				continue
+			}
+			if doc := parse(account, aws.StringValue(v.Document)); doc != nil {
+				managed[aws.StringValue(p.Arn)] = doc
+			}
+		}
+	}
+
+	groups := make(map[string]*Principal)
+	for _, g := range details.GroupDetailList {
+		p := &Principal{Type: TypeGroup, Name: aws.StringValue(g.GroupName), Arn: aws.StringValue(g.Arn)}
+		p.addPolicies(account, managed, g.GroupPolicyList, g.AttachedManagedPolicies)
+		groups[p.Name] = p
+		ag.Principals = append(ag.Principals, p)
+	}
+
+	for _, u := range details.UserDetailList {
+		p := &Principal{Type: TypeUser, Name: aws.StringValue(u.UserName), Arn: aws.StringValue(u.Arn)}
+		p.addPolicies(account, managed, u.UserPolicyList, u.AttachedManagedPolicies)
+		for _, name := range u.GroupList {
+			if g, ok := groups[aws.StringValue(name)]; ok {
+				p.Groups = append(p.Groups, g.Name)
+				p.Managed = append(p.Managed, g.Managed...)
+				p.Documents = append(p.Documents, g.Documents...)
+			}
+		}
+		ag.Principals = append(ag.Principals, p)
+	}
+
+	for _, r := range details.RoleDetailList {
+		p := &Principal{Type: TypeRole, Name: aws.StringValue(r.RoleName), Arn: aws.StringValue(r.Arn)}
+		p.addPolicies(account, managed, r.RolePolicyList, r.AttachedManagedPolicies)
+		p.Trust = parse(account, aws.StringValue(r.AssumeRolePolicyDocument))
+		ag.Principals = append(ag.Principals, p)
+	}
+	return ag
+}
+
+func (p *Principal) addPolicies(account oaws.Account, managed map[string]*policy.Document, inline []*iam.PolicyDetail, attached []*iam.AttachedPolicy) {
+	for _, pd := range inline {
+		if doc := parse(account, aws.StringValue(pd.PolicyDocument)); doc != nil {
+			p.Documents = append(p.Documents, doc)
+		}
+	}
+	for _, ap := range attached {
+		arn := aws.StringValue(ap.PolicyArn)
+		p.Managed = append(p.Managed, arn)
+		if doc, ok := managed[arn]; ok {
+			p.Documents = append(p.Documents, doc)
+		}
+	}
+}
+
+func parse(account oaws.Account, document string) *policy.Document {
+	doc, err := policy.Parse(document)
+	if err != nil {
+		log.Warnf("Could not parse policy document in account [%s]: %+v", account.Name, err)
+		return nil
+	}
+	return doc
+}
+
+// CheckPolicy returns users and roles that are not admins, but can escalate to admin,
+// along with the shortest escalation path
+func (ag *AG) CheckPolicy() *PV {
+	log.Debugf("Checking privilege escalation paths in account [%s]", ag.Account.Name)
+	violations := &PV{Account: ag.Account}
+
+	graph := make(map[string][]Edge)
+	for _, p := range ag.Principals {
+		if p.Type != TypeGroup {
+			graph[p.Node()] = ag.edges(p)
+		}
+	}
+
+	for _, p := range ag.Principals {
+		if p.Type == TypeGroup || isAdmin(p) {
+			continue
+		}
+		if path := shortestPath(graph, p.Node()); path != nil {
+			violations.Violations = append(violations.Violations, Violation{Principal: p, Path: path})
+		}
+	}
+	return violations
+}
+
+// edges returns the principals (or Admin) a user or role can act as, using its own permissions
+func (ag *AG) edges(p *Principal) []Edge {
+	var edges []Edge
+	add := func(to, via string) {
+		edges = append(edges, Edge{To: to, Via: via})
+	}
+	allows := func(action, resource string) bool {
+		return policy.Allows(p.Documents, action, resource)
+	}
+
+	if isAdmin(p) {
+		add(Admin, "admin-equivalent permissions")
+		return edges
+	}
+
+	// modify the policies that apply to the principal itself
+	for _, arn := range p.Managed {
+		if !strings.Contains(arn, ":iam::aws:policy/") && allows("iam:CreatePolicyVersion", arn) {
+			add(Admin, "iam:CreatePolicyVersion on "+arn)
+		}
+	}
+	switch p.Type {
+	case TypeUser:
+		for _, action := range []string{"iam:AttachUserPolicy", "iam:PutUserPolicy"} {
+			if allows(action, p.Arn) {
+				add(Admin, action+" on self")
+			}
+		}
+		for _, g := range p.Groups {
+			for _, action := range []string{"iam:AttachGroupPolicy", "iam:PutGroupPolicy"} {
+				if group := ag.find(TypeGroup, g); group != nil && allows(action, group.Arn) {
+					add(Admin, action+" on "+group.Arn)
+				}
+			}
+		}
+	case TypeRole:
+		for _, action := range []string{"iam:AttachRolePolicy", "iam:PutRolePolicy"} {
+			if allows(action, p.Arn) {
+				add(Admin, action+" on self")
+			}
+		}
+	}
+
+	for _, target := range ag.Principals {
+		if target == p {
+			continue
+		}
+		switch target.Type {
+		case TypeGroup:
+			if p.Type == TypeUser && isAdmin(target) && allows("iam:AddUserToGroup", target.Arn) {
+				add(Admin, "iam:AddUserToGroup on "+target.Arn)
+			}
+		case TypeUser:
+			for _, action := range []string{"iam:CreateAccessKey", "iam:CreateLoginProfile", "iam:UpdateLoginProfile"} {
+				if allows(action, target.Arn) {
+					add(target.Node(), action)
+					break
+				}
+			}
+		case TypeRole:
+			if via := ag.assumeRole(p, target); via != "" {
+				add(target.Node(), via)
+			}
+		}
+	}
+	return edges
+}
+
+// assumeRole returns how p can act as the target role, or an empty string if it cannot
+func (ag *AG) assumeRole(p, target *Principal) string {
+	allows := func(action, resource string) bool {
+		return policy.Allows(p.Documents, action, resource)
+	}
+	if allows("sts:AssumeRole", target.Arn) {
+		if trusts(target.Trust, p.Arn, ag.Account.Number) {
+			return "sts:AssumeRole"
+		}
+		if allows("iam:UpdateAssumeRolePolicy", target.Arn) {
+			return "iam:UpdateAssumeRolePolicy + sts:AssumeRole"
+		}
+	}
+	if !allows("iam:PassRole", target.Arn) {
+		return ""
+	}
+	for service, actions := range passRoleServices {
+		if !trusts(target.Trust, service, "") {
+			continue
+		}
+		passable := true
+		for _, action := range actions {
+			if !policy.AllowsAny(p.Documents, action) {
+				passable = false
+				break
+			}
+		}
+		if passable {
+			return "iam:PassRole + " + strings.Join(actions, " + ")
+		}
+	}
+	return ""
+}
+
+func (ag *AG) find(principalType, name string) *Principal {
+	for _, p := range ag.Principals {
+		if p.Type == principalType && p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// trusts returns true if the trust policy allows the principal (an ARN or a service) to assume the role.
+// Trusting the account root trusts all principals of the account that are allowed to assume the role.
+func trusts(trust *policy.Document, principal, account string) bool {
+	if trust == nil {
+		return false
+	}
+	for _, stmt := range trust.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+		for _, values := range stmt.Principal {
+			for _, v := range values {
+				if v == "*" || v == principal || (account != "" && (v == account || v == "arn:aws:iam::"+account+":root")) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isAdmin returns true if the principal holds all permissions or all IAM permissions.
+// Conditional Denies (e.g. force-MFA policies) do not prevent a principal from being admin.
+func isAdmin(p *Principal) bool {
+	return policy.Allows(p.Documents, "iam:*", "*") || policy.Allows(p.Documents, "*:*", "*")
+}
+
+// shortestPath returns the shortest path from the node to Admin, or nil if there is none
+func shortestPath(graph map[string][]Edge, from string) []Edge {
+	prev := map[string]Edge{}
+	parent := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, e := range graph[node] {
+			if _, seen := parent[e.To]; seen {
+				continue
+			}
+			parent[e.To] = node
+			prev[e.To] = e
+			if e.To == Admin {
+				var path []Edge
+				for n := Admin; n != from; n = parent[n] {
+					path = append([]Edge{prev[n]}, path...)
+				}
+				return path
+			}
+			queue = append(queue, e.To)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package iam
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+// RolePolicies returns the inline and attached managed policy documents of a role, keyed by policy name
+func RolePolicies(client *iam.IAM, roleName string) (map[string]*policy.Document, error) {
+	docs := make(map[string]*policy.Document)
+
+	inline, err := client.ListRolePolicies(&iam.ListRolePoliciesInput{RoleName: &roleName})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range inline.PolicyNames {
+		rp, err := client.GetRolePolicy(&iam.GetRolePolicyInput{RoleName: &roleName, PolicyName: name})
+		if err != nil {
+			return nil, err
+		}
+		doc, err := policy.Parse(*rp.PolicyDocument)
+		if err != nil {
+			log.Warnf("Could not parse inline policy [%s] of role [%s]: %+v", *name, roleName, err)
+			continue
+		}
+		docs[*name] = doc
+	}
+
+	attached, err := client.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: &roleName})
+	if err != nil {
+		return nil, err
+	}
+	for _, ap := range attached.AttachedPolicies {
+		document, err := defaultVersionDocument(client, ap.PolicyArn)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := policy.Parse(document)
+		if err != nil {
+			log.Warnf("Could not parse managed policy [%s] of role [%s]: %+v", *ap.PolicyName, roleName, err)
+			continue
+		}
+		docs[*ap.PolicyName] = doc
+	}
+	return docs, nil
+}
+
+// RoleBoundary returns the permissions boundary policy document of a role, or nil if it has none
+func RoleBoundary(client *iam.IAM, roleName string) (*policy.Document, error) {
+	r, err := client.GetRole(&iam.GetRoleInput{RoleName: &roleName})
+	if err != nil {
+		return nil, err
+	}
+	if r.Role.PermissionsBoundary == nil {
+		return nil, nil
+	}
+	document, err := defaultVersionDocument(client, r.Role.PermissionsBoundary.PermissionsBoundaryArn)
+	if err != nil {
+		return nil, err
+	}
+	return policy.Parse(document)
+}
+
+// defaultVersionDocument returns the document of the default version of a managed policy
+func defaultVersionDocument(client *iam.IAM, arn *string) (string, error) {
+	p, err := client.GetPolicy(&iam.GetPolicyInput{PolicyArn: arn})
+	if err != nil {
+		return "", err
+	}
+	pv, err := client.GetPolicyVersion(&iam.GetPolicyVersionInput{PolicyArn: arn, VersionId: p.Policy.DefaultVersionId})
+	if err != nil {
+		return "", err
+	}
+	return *pv.PolicyVersion.Document, nil
+}
@@ -0,0 +1,147 @@
+package root
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/account"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/credreport"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+)
+
+// AlternateContactTypes are the alternate contacts every account must configure
+var AlternateContactTypes = []string{
+	account.AlternateContactTypeBilling,
+	account.AlternateContactTypeOperations,
+	account.AlternateContactTypeSecurity,
+}
+
+// Policy represents the root account policy.
+// MaxDays is the no. of days in which the root account must not have been used.
+type Policy struct {
+	MaxDays            int
+	RequireHardwareMFA bool
+}
+
+// RA holds the security configuration of the root account per account.
+type RA struct {
+	Account oaws.Account
+	// Summary is the IAM account summary, e.g. AccountMFAEnabled and AccountAccessKeysPresent
+	Summary map[string]*int64
+	// Entry is the credential report entry of the root account
+	Entry *credreport.Entry
+	// VirtualMFA is true if the root account MFA device is virtual
+	VirtualMFA bool
+	// AlternateContacts is keyed by alternate contact type, and is true if the contact is configured
+	AlternateContacts map[string]bool
+}
+
+// RV holds root account violations per account.
+type RV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a root account policy violation
+type Violation struct {
+	Rule   string
+	Detail string
+}
+
+// List retrieves the security configuration of the root account
+func List(acct oaws.Account) *RA {
+	ra := &RA{Account: acct, AlternateContacts: make(map[string]bool)}
+	client := oiam.Client(acct)
+
+	summary, err := client.GetAccountSummary(&iam.GetAccountSummaryInput{})
+	if err != nil {
+		log.Debugf("Could not get account summary in account [%s]: %+v", acct.Name, err)
+	} else {
+		ra.Summary = summary.SummaryMap
+	}
+
+	report, err := credreport.Get(acct)
+	if err != nil {
+		log.Debugf("Could not get credential report in account [%s]: %+v", acct.Name, err)
+	} else {
+		ra.Entry = report.Root()
+	}
+
+	vmds, err := client.ListVirtualMFADevices(&iam.ListVirtualMFADevicesInput{AssignmentStatus: aws.String(iam.AssignmentStatusTypeAssigned)})
+	if err != nil {
+		log.Debugf("Could not list virtual MFA devices in account [%s]: %+v", acct.Name, err)
+	} else {
+		for _, vmd := range vmds.VirtualMFADevices {
+			if vmd.User != nil && strings.HasSuffix(aws.StringValue(vmd.User.Arn), ":root") {
+				ra.VirtualMFA = true
+			}
+		}
+	}
+
+	ac := accountClient(acct)
+	for _, t := range AlternateContactTypes {
+		_, err := ac.GetAlternateContact(&account.GetAlternateContactInput{AlternateContactType: aws.String(t)})
+		if err == nil {
+			ra.AlternateContacts[t] = true
+			continue
+		}
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == account.ErrCodeResourceNotFoundException {
+			ra.AlternateContacts[t] = false
+			continue
+		}
+		log.Debugf("Could not get %s alternate contact in account [%s]: %+v", t, acct.Name, err)
+	}
+	return ra
+}
+
+// CheckPolicy returns root account violations
+func (ra *RA) CheckPolicy(p Policy) *RV {
+	log.Debugf("Checking root account in account [%s]", ra.Account.Name)
+	violations := &RV{Account: ra.Account}
+	add := func(rule, detail string) {
+		violations.Violations = append(violations.Violations, Violation{Rule: rule, Detail: detail})
+	}
+
+	if ra.Summary != nil {
+		if aws.Int64Value(ra.Summary["AccountMFAEnabled"]) == 0 {
+			add("Root MFA Disabled", "")
+		} else if p.RequireHardwareMFA && ra.VirtualMFA {
+			add("Root Virtual MFA", "hardware MFA is required")
+		}
+		if n := aws.Int64Value(ra.Summary["AccountAccessKeysPresent"]); n > 0 {
+			add("Root Access Keys", fmt.Sprintf("%d access keys present", n))
+		}
+	}
+
+	if ra.Entry != nil {
+		lastUsed, credential := users.LastActivity(ra.Entry)
+		if lastUsed != nil && time.Since(*lastUsed) < time.Duration(p.MaxDays)*24*time.Hour {
+			add("Root Account Used", fmt.Sprintf("%s last used on %s", credential, lastUsed.Format("2006-01-02")))
+		}
+	}
+
+	for _, t := range AlternateContactTypes {
+		if configured, ok := ra.AlternateContacts[t]; ok && !configured {
+			add("Missing Alternate Contact", t)
+		}
+	}
+	return violations
+}
+
+func accountClient(acct oaws.Account) *account.Account {
+	creds := credentials.NewStaticCredentials(acct.AccessKey, acct.SecretKey, acct.Token)
+	creds.Get()
+	log.Debugf("Retrieved Credentials for: %+v", acct.Name)
+	return account.New(
+		session.Must(session.NewSession()),
+		aws.NewConfig().WithCredentials(creds).WithRegion("us-east-1"))
+}
@@ -0,0 +1,113 @@
+package trust
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+// AR holds roles and their trust policies per account.
+type AR struct {
+	Account oaws.Account
+	Roles   []Role
+}
+
+// Role represents a role and its trust policy
+type Role struct {
+	Name  string
+	Arn   string
+	Trust *policy.Document
+}
+
+// RV holds trust policy violations per account.
+type RV struct {
+	Account    oaws.Account
+	Violations []Violation
+}
+
+// Violation represents a role trusting a principal it should not
+type Violation struct {
+	Role      Role
+	Principal string
+	Rule      string
+	Detail    string
+}
+
+// List returns all roles of the account along with their trust policies
+func List(account oaws.Account) *AR {
+	ar := &AR{Account: account}
+	err := oiam.Client(account).ListRolesPages(&iam.ListRolesInput{}, func(page *iam.ListRolesOutput, lastPage bool) bool {
+		for _, r := range page.Roles {
+			doc, err := policy.Parse(aws.StringValue(r.AssumeRolePolicyDocument))
+			if err != nil {
+				log.Warnf("Could not parse trust policy of role [%s] in account [%s]: %+v", aws.StringValue(r.RoleName), account.Name, err)
+				continue
+			}
+			ar.Roles = append(ar.Roles, Role{Name: aws.StringValue(r.RoleName), Arn: aws.StringValue(r.Arn), Trust: doc})
+		}
+		return true
+	})
+	if err != nil {
+		log.Debugf("Could not list roles in account [%s]: %+v", account.Name, err)
+		return ar
+	}
+	log.Debugf("Listed %d roles in account [%s]", len(ar.Roles), account.Name)
+	return ar
+}
+
+// CheckPolicy returns roles that trust wildcard principals, principals that are not account numbers or ARNs,
+// accounts outside of the trusted accounts, external accounts without an sts:ExternalId condition,
+// or federated providers without conditions
+func (ar *AR) CheckPolicy(trusted []string) *RV {
+	log.Debugf("Checking role trust policies in account [%s]", ar.Account.Name)
+	violations := &RV{Account: ar.Account}
+	add := func(role Role, principal, rule, detail string) {
+		violations.Violations = append(violations.Violations, Violation{Role: role, Principal: principal, Rule: rule, Detail: detail})
+	}
+
+	for _, role := range ar.Roles {
+		for _, stmt := range role.Trust.Statement {
+			if stmt.Effect != "Allow" {
+				continue
+			}
+			conditioned := len(stmt.Condition) > 0
+
+			for _, principal := range stmt.Principal["AWS"] {
+				// wildcards also match any account in ARNs (e.g. arn:aws:iam::*:root)
+				if strings.ContainsAny(principal, "*?") {
+					add(role, principal, "Wildcard Trust", fmt.Sprintf("conditions: %t", conditioned))
+					continue
+				}
+				account := policy.AccountID(principal)
+				if account == "" {
+					add(role, principal, "Unparsed Trust Principal", "principal is not an account number or ARN")
+					continue
+				}
+				if account == ar.Account.Number || oaws.IsTrusted(account, trusted) {
+					continue
+				}
+				add(role, principal, "External Account Trust", account)
+				if !stmt.HasConditionKey("sts:ExternalId") {
+					add(role, principal, "Missing External ID", account)
+				}
+			}
+
+			for _, principal := range stmt.Principal["Federated"] {
+				if !conditioned {
+					add(role, principal, "Unconditioned Federated Trust", "")
+				}
+			}
+
+			if len(stmt.NotPrincipal) > 0 {
+				add(role, "", "Wildcard Trust", "NotPrincipal trusts all other principals")
+			}
+		}
+	}
+	return violations
+}
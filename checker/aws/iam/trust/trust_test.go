@@ -0,0 +1,58 @@
+package trust
+
+import (
+	"reflect"
+	"testing"
+
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "wildcard principal",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "sts:AssumeRole"}}`,
+			want: []string{"Wildcard Trust *"},
+		},
+		{
+			name: "wildcard account in ARN",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::*:root"}, "Action": "sts:AssumeRole"}}`,
+			want: []string{"Wildcard Trust arn:aws:iam::*:root"},
+		},
+		{
+			name: "unique ID of a deleted role",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "AROAEXAMPLEUNIQUEID"}, "Action": "sts:AssumeRole"}}`,
+			want: []string{"Unparsed Trust Principal AROAEXAMPLEUNIQUEID"},
+		},
+		{
+			name: "same and trusted accounts",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::111122223333:root", "444455556666"]}, "Action": "sts:AssumeRole"}}`,
+		},
+		{
+			name: "external account without external ID",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::777788889999:root"}, "Action": "sts:AssumeRole"}}`,
+			want: []string{"External Account Trust arn:aws:iam::777788889999:root", "Missing External ID arn:aws:iam::777788889999:root"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := policy.Parse(tt.doc)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			ar := &AR{Account: oaws.Account{Number: "111122223333"}, Roles: []Role{{Name: "role", Trust: doc}}}
+			var got []string
+			for _, v := range ar.CheckPolicy([]string{"444455556666"}).Violations {
+				got = append(got, v.Rule+" "+v.Principal)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
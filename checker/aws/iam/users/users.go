@@ -4,50 +4,72 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/aws/aws-sdk-go/service/iam"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
-	oiam "github.com/petermbenjamin/orthrus/checker/aws/iam"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/credreport"
 )
 
 // AU holds slice of users per account.
-// AU is used to hold all users per account or to hold a slice of users who are inactive for more than 90 days.
 type AU struct {
 	Account oaws.Account
-	Users   []*iam.User
+	Users   []*credreport.Entry
 }
 
-// List enumerates all AWS IAM users
+// IU holds a slice of users per account who are inactive for more than the max no. of days.
+type IU struct {
+	Account oaws.Account
+	Users   []*InactiveUser
+}
+
+// Credential types that may drive the inactivity decision
+const (
+	CredentialNone       = "none"
+	CredentialPassword   = "password"
+	CredentialAccessKey1 = "access_key_1"
+	CredentialAccessKey2 = "access_key_2"
+)
+
+// InactiveUser represents an inactive user and the credential that was used most recently.
+// LastUsed is nil and Credential is CredentialNone if no credential was ever used.
+type InactiveUser struct {
+	*credreport.Entry
+	LastUsed   *time.Time
+	Credential string
+}
+
+// List enumerates all AWS IAM users from the credential report
 func List(account oaws.Account) *AU {
 	userList := &AU{Account: account}
-	iamResp, err := oiam.Client(account).ListUsers(&iam.ListUsersInput{})
+	report, err := credreport.Get(account)
 	if err != nil {
-		log.Debugf("Could not list users in account [%s]", account.Name)
+		log.Debugf("Could not get credential report in account [%s]: %+v", account.Name, err)
 		return userList
 	}
+	userList.Users = report.Users()
 	log.Debugf("Listed %d users in account [%s]", len(userList.Users), userList.Account.Name)
-	userList.Users = iamResp.Users
 	return userList
 }
 
 // CheckPolicy returns all inactive users per account.
-func (au *AU) CheckPolicy(userMaxDays int) *AU {
-	log.Debugln("Checking user inactivity in account [%s]", au.Account.Name)
+// A user is inactive if none of their credentials were used in the max no. of days,
+// or if they never used any credential and were created more than the max no. of days ago.
+func (au *AU) CheckPolicy(userMaxDays int) *IU {
+	log.Debugf("Checking user inactivity in account [%s]", au.Account.Name)
 
-	uc := make(chan *iam.User)
+	uc := make(chan *InactiveUser)
 	defer close(uc)
 
 	userMaxDuration := time.Duration(userMaxDays) * 24 * time.Hour
-	userViolations := &AU{Account: au.Account}
+	userViolations := &IU{Account: au.Account}
 
 	for i, user := range au.Users {
-		log.Debugf("[%d] Checking if User [%s] is inactive in account [%s]", i, user.UserName, au.Account.Name)
-		go func(user *iam.User) {
+		log.Debugf("[%d] Checking if User [%s] is inactive in account [%s]", i, user.User, au.Account.Name)
+		go func(user *credreport.Entry) {
 			uc <- isInactiveUser(user, userMaxDuration)
 		}(user)
 	}
 
 	for i, user := range au.Users {
-		log.Debugf("[%d] Retrieving value from channel for user [%s] in account [%s]", i, user.UserName, au.Account.Name)
+		log.Debugf("[%d] Retrieving value from channel for user [%s] in account [%s]", i, user.User, au.Account.Name)
 		select {
 		case v := <-uc:
 			if v != nil {
@@ -58,14 +80,37 @@ func (au *AU) CheckPolicy(userMaxDays int) *AU {
 	return userViolations
 }
 
-func isInactiveUser(user *iam.User, userMaxDuration time.Duration) *iam.User {
-	// TODO: check to see if user has programmatic access
-	if user.PasswordLastUsed != nil {
-		hoursSinceLastLogin := time.Since(*user.PasswordLastUsed)
-		if hoursSinceLastLogin > userMaxDuration {
-			log.Debugf("User [%s] is inactive", *user.UserName)
-			return user
+func isInactiveUser(user *credreport.Entry, userMaxDuration time.Duration) *InactiveUser {
+	lastUsed, credential := LastActivity(user)
+	if lastUsed == nil {
+		if user.UserCreationTime != nil && time.Since(*user.UserCreationTime) > userMaxDuration {
+			log.Debugf("User [%s] is inactive and never used any credential", user.User)
+			return &InactiveUser{Entry: user, Credential: credential}
 		}
+		return nil
+	}
+	if time.Since(*lastUsed) > userMaxDuration {
+		log.Debugf("User [%s] is inactive since [%s] was last used", user.User, credential)
+		return &InactiveUser{Entry: user, LastUsed: lastUsed, Credential: credential}
 	}
 	return nil
 }
+
+// LastActivity returns the most recent use of the password or any access key of a user
+func LastActivity(user *credreport.Entry) (*time.Time, string) {
+	var lastUsed *time.Time
+	credential := CredentialNone
+	for _, c := range []struct {
+		used       *time.Time
+		credential string
+	}{
+		{user.PasswordLastUsed, CredentialPassword},
+		{user.AccessKey1LastUsedDate, CredentialAccessKey1},
+		{user.AccessKey2LastUsedDate, CredentialAccessKey2},
+	} {
+		if c.used != nil && (lastUsed == nil || c.used.After(*lastUsed)) {
+			lastUsed, credential = c.used, c.credential
+		}
+	}
+	return lastUsed, credential
+}
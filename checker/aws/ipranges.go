@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// IPRangesURL is the URL of the published AWS IP address ranges
+const IPRangesURL = "https://ip-ranges.amazonaws.com/ip-ranges.json"
+
+// IPRangesServiceEC2 is the service of the EC2 address ranges, which include Elastic IPs and instance public IPs
+const IPRangesServiceEC2 = "EC2"
+
+var ipRangesClient = &http.Client{Timeout: 30 * time.Second}
+
+type ipRanges struct {
+	Prefixes []struct {
+		IPPrefix string `json:"ip_prefix"`
+		Service  string `json:"service"`
+	} `json:"prefixes"`
+}
+
+// IPRanges returns the published IPv4 address ranges of an AWS service (e.g. IPRangesServiceEC2)
+func IPRanges(service string) ([]*net.IPNet, error) {
+	resp, err := ipRangesClient.Get(IPRangesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not retrieve %s: %s", IPRangesURL, resp.Status)
+	}
+
+	var r ipRanges
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	var ranges []*net.IPNet
+	for _, p := range r.Prefixes {
+		if p.Service != service {
+			continue
+		}
+		_, n, err := net.ParseCIDR(p.IPPrefix)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, n)
+	}
+	return ranges, nil
+}
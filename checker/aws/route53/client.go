@@ -0,0 +1,20 @@
+package route53
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+// Client instantiates and returns a Route 53 client
+func Client(account oaws.Account) *route53.Route53 {
+	creds := credentials.NewStaticCredentials(account.AccessKey, account.SecretKey, account.Token)
+	creds.Get()
+	log.Debugf("Retrieved Credentials for: %+v", account.Name)
+	return route53.New(
+		session.Must(session.NewSession()),
+		aws.NewConfig().WithCredentials(creds))
+}
@@ -0,0 +1,79 @@
+package route53
+
+import (
+	"net"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+// AR represents A records per account.
+type AR struct {
+	Account oaws.Account
+	Records []Record
+}
+
+// Record represents a single IP of an A record
+type Record struct {
+	Zone string
+	Name string
+	IP   string
+}
+
+// List returns the IPs of all A records in all hosted zones of the account.
+// Alias records are skipped, since they do not point at IPs.
+func List(account oaws.Account) *AR {
+	ar := &AR{Account: account}
+	client := Client(account)
+
+	err := client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(zones *route53.ListHostedZonesOutput, lastPage bool) bool {
+		for _, zone := range zones.HostedZones {
+			err := client.ListResourceRecordSetsPages(&route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id}, func(sets *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+				for _, rrs := range sets.ResourceRecordSets {
+					if aws.StringValue(rrs.Type) != route53.RRTypeA || rrs.AliasTarget != nil {
+						continue
+					}
+					for _, rr := range rrs.ResourceRecords {
+						ar.Records = append(ar.Records, Record{Zone: *zone.Name, Name: *rrs.Name, IP: aws.StringValue(rr.Value)})
+					}
+				}
+				return true
+			})
+			if err != nil {
+				log.Debugf("Could not list records of hosted zone [%s] in account [%s]: %+v", *zone.Name, account.Name, err)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		log.Debugf("Could not list hosted zones in account [%s]: %+v", account.Name, err)
+		return ar
+	}
+	log.Debugf("Listed %d A records in account [%s]", len(ar.Records), account.Name)
+	return ar
+}
+
+// CheckPolicy returns A records pointing at IPs in the given ranges (e.g. EC2 IP ranges) that are not owned by any account.
+// Only EC2 ranges should be given, since addresses of other services (e.g. CloudFront, API Gateway) are never owned by an account.
+func (ar *AR) CheckPolicy(owned []string, ranges []*net.IPNet) *AR {
+	violations := &AR{Account: ar.Account}
+	ownedIPs := make(map[string]bool)
+	for _, ip := range owned {
+		ownedIPs[ip] = true
+	}
+	for _, r := range ar.Records {
+		if ownedIPs[r.IP] {
+			continue
+		}
+		ip := net.ParseIP(r.IP)
+		for _, n := range ranges {
+			if ip != nil && n.Contains(ip) {
+				violations.Records = append(violations.Records, r)
+				break
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+// public grantee groups, keyed by group URI
+var publicGroups = map[string]string{
+	"http://acs.amazonaws.com/groups/global/AllUsers":           "AllUsers",
+	"http://acs.amazonaws.com/groups/global/AuthenticatedUsers": "AuthenticatedUsers",
+}
+
+// publicACLGrants returns the permissions (e.g. READ, WRITE, READ_ACP, WRITE_ACP, FULL_CONTROL)
+// the grants give to AllUsers or AuthenticatedUsers
+func publicACLGrants(grants []*s3.Grant) []string {
+	var public []string
+	for _, g := range grants {
+		if g.Grantee == nil || aws.StringValue(g.Grantee.Type) != s3.TypeGroup {
+			continue
+		}
+		if group, ok := publicGroups[aws.StringValue(g.Grantee.URI)]; ok {
+			public = append(public, group+":"+aws.StringValue(g.Permission))
+		}
+	}
+	return public
+}
+
+func getPublicBucketACL(client *s3.S3, bucket string, account oaws.Account) []string {
+	acl, err := client.GetBucketAcl(&s3.GetBucketAclInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		log.Debugf("Could not retrieve Bucket ACL for Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+		return nil
+	}
+	return publicACLGrants(acl.Grants)
+}
+
+// samplePublicObjectACLs checks the ACLs of up to n objects of the bucket,
+// and returns the public permissions they grant keyed by object key
+func samplePublicObjectACLs(client *s3.S3, bucket string, account oaws.Account, n int) map[string][]string {
+	objects, err := client.ListObjectsV2(&s3.ListObjectsV2Input{Bucket: aws.String(bucket), MaxKeys: aws.Int64(int64(n))})
+	if err != nil {
+		log.Debugf("Could not list Objects in Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+		return nil
+	}
+
+	public := make(map[string][]string)
+	for _, o := range objects.Contents {
+		acl, err := client.GetObjectAcl(&s3.GetObjectAclInput{Bucket: aws.String(bucket), Key: o.Key})
+		if err != nil {
+			log.Debugf("Could not retrieve Object ACL for Object [%s] in Bucket [%s] in Account [%s]: %+v", aws.StringValue(o.Key), bucket, account.Name, err)
+			continue
+		}
+		if grants := publicACLGrants(acl.Grants); len(grants) > 0 {
+			public[aws.StringValue(o.Key)] = grants
+		}
+	}
+	if len(public) == 0 {
+		return nil
+	}
+	return public
+}
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3control"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+const errCodeNoSuchPublicAccessBlockConfiguration = "NoSuchPublicAccessBlockConfiguration"
+
+// BlockPublicAccess represents S3 Block Public Access settings
+type BlockPublicAccess struct {
+	BlockPublicAcls       bool
+	IgnorePublicAcls      bool
+	BlockPublicPolicy     bool
+	RestrictPublicBuckets bool
+}
+
+// UnblockedBucket represents a bucket whose effective Block Public Access settings are missing or partially disabled
+type UnblockedBucket struct {
+	Name     string
+	Disabled []string
+}
+
+// Disabled returns the names of the disabled settings
+func (b BlockPublicAccess) Disabled() []string {
+	var disabled []string
+	for _, s := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"BlockPublicAcls", b.BlockPublicAcls},
+		{"IgnorePublicAcls", b.IgnorePublicAcls},
+		{"BlockPublicPolicy", b.BlockPublicPolicy},
+		{"RestrictPublicBuckets", b.RestrictPublicBuckets},
+	} {
+		if !s.enabled {
+			disabled = append(disabled, s.name)
+		}
+	}
+	return disabled
+}
+
+// effective combines account-level and bucket-level settings, since a setting enabled at either level applies
+func effective(account, bucket *BlockPublicAccess) BlockPublicAccess {
+	var e BlockPublicAccess
+	for _, b := range []*BlockPublicAccess{account, bucket} {
+		if b == nil {
+			continue
+		}
+		e.BlockPublicAcls = e.BlockPublicAcls || b.BlockPublicAcls
+		e.IgnorePublicAcls = e.IgnorePublicAcls || b.IgnorePublicAcls
+		e.BlockPublicPolicy = e.BlockPublicPolicy || b.BlockPublicPolicy
+		e.RestrictPublicBuckets = e.RestrictPublicBuckets || b.RestrictPublicBuckets
+	}
+	return e
+}
+
+// getAccountBlockPublicAccess returns the account-level Block Public Access settings,
+// or nil if they are not configured or could not be retrieved
+func getAccountBlockPublicAccess(account oaws.Account) *BlockPublicAccess {
+	if account.Number == "" {
+		log.Debugf("Account [%s] has no account number, skipping account-level Block Public Access", account.Name)
+		return nil
+	}
+	out, err := ControlClient(account).GetPublicAccessBlock(&s3control.GetPublicAccessBlockInput{AccountId: aws.String(account.Number)})
+	if err != nil {
+		logBlockPublicAccessErr(err, "Account ["+account.Name+"]")
+		return nil
+	}
+	c := out.PublicAccessBlockConfiguration
+	return &BlockPublicAccess{
+		BlockPublicAcls:       aws.BoolValue(c.BlockPublicAcls),
+		IgnorePublicAcls:      aws.BoolValue(c.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.BoolValue(c.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.BoolValue(c.RestrictPublicBuckets),
+	}
+}
+
+// getBucketBlockPublicAccess returns the bucket-level Block Public Access settings,
+// or nil if they are not configured or could not be retrieved
+func getBucketBlockPublicAccess(client *s3.S3, bucket string) *BlockPublicAccess {
+	out, err := client.GetPublicAccessBlock(&s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		logBlockPublicAccessErr(err, "Bucket ["+bucket+"]")
+		return nil
+	}
+	c := out.PublicAccessBlockConfiguration
+	return &BlockPublicAccess{
+		BlockPublicAcls:       aws.BoolValue(c.BlockPublicAcls),
+		IgnorePublicAcls:      aws.BoolValue(c.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.BoolValue(c.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.BoolValue(c.RestrictPublicBuckets),
+	}
+}
+
+func logBlockPublicAccessErr(err error, target string) {
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeNoSuchPublicAccessBlockConfiguration {
+		log.Debugf("%s has no Block Public Access configuration", target)
+		return
+	}
+	log.Debugf("Could not retrieve Block Public Access configuration for %s: %+v", target, err)
+}
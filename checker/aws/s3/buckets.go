@@ -1,48 +1,67 @@
 package s3
 
 import (
-	"context"
-	"encoding/json"
-
 	log "github.com/Sirupsen/logrus"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
 )
 
-type policy struct {
-	Statement []statement `json:"Statement"`
-	Version   string      `json:"Version"`
+// BucketViolator struct is an object that contains the violating buckets and the AWS account they exist in.
+// Unblocked holds buckets whose Block Public Access settings are missing or partially disabled.
+type BucketViolator struct {
+	Account   oaws.Account
+	Buckets   []PublicBucket
+	Unblocked []UnblockedBucket
 }
 
-type statement struct {
-	Action    interface{} `json:"Action"`
-	Condition interface{} `json:"Condition"`
-	Effect    interface{} `json:"Effect"`
-	Principal interface{} `json:"Principal"`
-	Resource  interface{} `json:"Resource"`
-	Sid       interface{} `json:"Sid"`
+// Policy represents the public bucket policy.
+// SampleObjects is the no. of objects per bucket whose ACLs are checked. Zero disables object ACL checks.
+// Sampling configures the opt-in sampling of sensitive objects in public buckets.
+type Policy struct {
+	SampleObjects int
+	Sampling      Sampling
 }
 
-// BucketViolator struct is an object that contains the violating bucket name and the AWS account it exists in.
-type BucketViolator struct {
-	Account oaws.Account
-	Buckets []string
+// PublicBucket represents a public bucket and what it grants to everyone:
+// actions granted by its policy, permissions granted by its ACL, and permissions granted by the ACLs of sampled objects keyed by object key.
+// Grants neutralized by Block Public Access (RestrictPublicBuckets for policies, IgnorePublicAcls for ACLs) are omitted.
+// BlockPublicPolicy does not omit policy grants: it only rejects new public policies, and the existing policy stays in effect.
+// SensitiveObjects holds the sampled objects whose keys match sensitive patterns.
+type PublicBucket struct {
+	Name              string
+	PolicyGrants      []string
+	ACLGrants         []string
+	ObjectACLGrants   map[string][]string
+	BlockPublicAccess BlockPublicAccess
+	SensitiveObjects  []SensitiveObject
+}
+
+func (pb *PublicBucket) isPublic() bool {
+	return len(pb.PolicyGrants) > 0 || len(pb.ACLGrants) > 0 || len(pb.ObjectACLGrants) > 0
 }
 
-// AB represents an account and all its buckets
+// AB represents an account, all its buckets, its account-level Block Public Access settings,
+// and the bucket region and client cache shared by all S3 checks of the account
 type AB struct {
-	Account oaws.Account
-	Buckets []*s3.Bucket
+	Account           oaws.Account
+	Buckets           []*s3.Bucket
+	BlockPublicAccess *BlockPublicAccess
+	Regions           *Regions
+}
+
+// bucketResult is the outcome of checking a single bucket
+type bucketResult struct {
+	public    *PublicBucket
+	unblocked *UnblockedBucket
 }
 
 // List returns a list of S3 Bucket Policies for all S3 buckets in all regions
 func List(account oaws.Account) *AB {
-	ab := &AB{Account: account}
+	ab := &AB{Account: account, Regions: RegionsFor(account)}
 	var listParams *s3.ListBucketsInput
-	s3Client := ClientWithRegion(account, "us-west-2")
+	s3Client := ab.Regions.Client(defaultRegion)
 	log.Debugf("Listing Buckets in Account: %s", account.Name)
 	buckets, err := s3Client.ListBuckets(listParams)
 	if err != nil {
@@ -51,12 +70,14 @@ func List(account oaws.Account) *AB {
 	}
 	log.Debugf("Listed %d Buckets in Account [%s]", len(buckets.Buckets), account.Name)
 	ab.Buckets = buckets.Buckets
+	ab.BlockPublicAccess = getAccountBlockPublicAccess(account)
 	return ab
 }
 
-// CheckPolicy returns all public buckets for a given account
-func (ab *AB) CheckPolicy() *BucketViolator {
-	bc := make(chan string)
+// CheckPolicy returns all buckets for a given account that are public through their policy, their ACL, or the ACLs of sampled objects,
+// and all buckets whose Block Public Access settings are missing or partially disabled
+func (ab *AB) CheckPolicy(p Policy) *BucketViolator {
+	bc := make(chan bucketResult)
 	defer close(bc) // ensure channel is closed when CheckPolicy function returns/exits
 
 	bv := &BucketViolator{Account: ab.Account}
@@ -67,7 +88,7 @@ func (ab *AB) CheckPolicy() *BucketViolator {
 	for i, bucket := range ab.Buckets {
 		log.Debugf("[%d] Checking Bucket Policy on bucket [%s] in Account [%s]:", i, *bucket.Name, ab.Account.Name)
 		go func(bucket *s3.Bucket) {
-			bc <- ab.isPublicBucket(bucket)
+			bc <- ab.checkBucket(bucket, p)
 		}(bucket)
 	}
 
@@ -75,37 +96,70 @@ func (ab *AB) CheckPolicy() *BucketViolator {
 		log.Debugln(i, b)
 		select {
 		case v := <-bc:
-			if v != "" {
-				bv.Buckets = append(bv.Buckets, v)
+			if v.public != nil {
+				bv.Buckets = append(bv.Buckets, *v.public)
+			}
+			if v.unblocked != nil {
+				bv.Unblocked = append(bv.Unblocked, *v.unblocked)
 			}
 		}
 	}
 	return bv
 }
 
-func (ab *AB) isPublicBucket(bucket *s3.Bucket) string {
+func (ab *AB) checkBucket(bucket *s3.Bucket, p Policy) bucketResult {
+	var result bucketResult
+	client, err := ab.Regions.BucketClient(*bucket.Name)
+	if err != nil {
+		return result
+	}
+
+	bpa := effective(ab.BlockPublicAccess, getBucketBlockPublicAccess(client, *bucket.Name))
+	if disabled := bpa.Disabled(); len(disabled) > 0 {
+		result.unblocked = &UnblockedBucket{Name: *bucket.Name, Disabled: disabled}
+	}
+	result.public = ab.isPublicBucket(client, bucket, bpa, p)
+	return result
+}
+
+func (ab *AB) isPublicBucket(client *s3.S3, bucket *s3.Bucket, bpa BlockPublicAccess, p Policy) *PublicBucket {
+	pb := &PublicBucket{Name: *bucket.Name, BlockPublicAccess: bpa}
+
 	log.Debugf("[%s] Checking Bucket Policy on Bucket [%s]", ab.Account.Name, *bucket.Name)
-	policyOut, err := getBucketPolicy(*bucket.Name, ab.Account)
+	policyOut, err := getBucketPolicy(client, *bucket.Name, ab.Account)
 	if err != nil {
 		log.Debugf("Could not list Bucket Policy for Bucket [%s] in Account [%s]", *bucket.Name, ab.Account.Name)
-		return ""
+	} else if bpa.RestrictPublicBuckets {
+		// unlike BlockPublicPolicy, which only rejects new public policies, RestrictPublicBuckets neutralizes the existing policy
+		log.Debugf("Bucket [%s] restricts public buckets, ignoring public policy grants", *bucket.Name)
+	} else {
+		pb.PolicyGrants = isPublic(policyOut, *bucket.Name)
 	}
-	if isPublic(policyOut, *bucket.Name) {
-		return *bucket.Name
+
+	if bpa.IgnorePublicAcls {
+		log.Debugf("Bucket [%s] ignores public ACLs", *bucket.Name)
+	} else {
+		log.Debugf("[%s] Checking Bucket ACL on Bucket [%s]", ab.Account.Name, *bucket.Name)
+		pb.ACLGrants = getPublicBucketACL(client, *bucket.Name, ab.Account)
+		if p.SampleObjects > 0 {
+			pb.ObjectACLGrants = samplePublicObjectACLs(client, *bucket.Name, ab.Account, p.SampleObjects)
+		}
+	}
+
+	if !pb.isPublic() {
+		return nil
 	}
-	return ""
+	if p.Sampling.MaxKeys > 0 {
+		log.Debugf("[%s] Sampling Objects in public Bucket [%s]", ab.Account.Name, *bucket.Name)
+		pb.SensitiveObjects = sampleSensitiveObjects(client, *bucket.Name, ab.Account, p.Sampling)
+	}
+	return pb
 }
 
-func getBucketPolicy(bucket string, account oaws.Account) (*s3.GetBucketPolicyOutput, error) {
+func getBucketPolicy(client *s3.S3, bucket string, account oaws.Account) (*s3.GetBucketPolicyOutput, error) {
 	// defer profile.Duration(time.Now(), "getBucketPolicy function")
-	region, err := s3manager.GetBucketRegion(context.Background(), session.Must(session.NewSession()), bucket, "us-east-1")
-	if err != nil {
-		log.Debugf("Could not retrieve Region for Bucket [%s] in Account [%s]", bucket, account.Name)
-		return nil, err
-	}
-	log.Debugf("Bucket [%s] was found in Region [%s]", bucket, region)
 	policyParams := s3.GetBucketPolicyInput{Bucket: aws.String(bucket)}
-	policyOutput, err := ClientWithRegion(account, region).GetBucketPolicy(&policyParams)
+	policyOutput, err := client.GetBucketPolicy(&policyParams)
 	if err != nil {
 		log.Debugf("Could not retrieve Bucket Policy for Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
 		return nil, err
@@ -113,38 +167,18 @@ func getBucketPolicy(bucket string, account oaws.Account) (*s3.GetBucketPolicyOu
 	return policyOutput, nil
 }
 
-func isPublic(po *s3.GetBucketPolicyOutput, bucket string) bool {
+// isPublic returns the actions the bucket policy grants to everyone
+func isPublic(po *s3.GetBucketPolicyOutput, bucket string) []string {
 	// defer profile.Duration(time.Now(), "isPublic function")
 	if po.Policy == nil {
-		return false
+		log.Debugf("Bucket [%s] Policy is empty", bucket)
+		return nil
 	}
 
-	var p policy
-	if err := json.Unmarshal([]byte(*po.Policy), &p); err != nil {
-		log.Debugf("Could not decipher Bucket Policy JSON: %+v", err)
-		return false
-	}
-
-	for _, stmt := range p.Statement {
-		log.Debugf("Bucket [%s] Policy Statement: %s", bucket, stmt)
-		if stmt.Effect == "Allow" && stmt.Action == "s3:GetObject" {
-			log.Debugf("Bucket [%s] Statement Effect is ALLOW and Statement Action is S3:GETOBJECT", bucket)
-			if pstring, ok := stmt.Principal.(string); ok {
-				if pstring == "*" {
-					log.Debugf("Prinicpal is *")
-					return true
-				}
-			}
-			if pmap, ok := stmt.Principal.(map[string]string); ok {
-				if pmap["AWS"] == "*" {
-					log.Debugf("Principal is AWS=*")
-					return true
-				}
-			}
-		}
-		return false
+	p, err := policy.Parse(*po.Policy)
+	if err != nil {
+		log.Warnf("Could not parse Bucket Policy of Bucket [%s]: %+v", bucket, err)
+		return nil
 	}
-
-	log.Debugf("Bucket [%s] Policy is empty", bucket)
-	return false
+	return publicGrants(p, bucket)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3control"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
 )
 
@@ -30,3 +31,14 @@ func ClientWithRegion(account oaws.Account, region string) *s3.S3 {
 		session.Must(session.NewSession()),
 		aws.NewConfig().WithRegion(region).WithCredentials(creds))
 }
+
+// ControlClient returns an S3 Control client, used for account-level S3 settings
+func ControlClient(account oaws.Account) *s3control.S3Control {
+	log.Debugf("Retrieving AWS API keys for account: %+v", account.Name)
+	creds := credentials.NewStaticCredentials(account.AccessKey, account.SecretKey, account.Token)
+	creds.Get()
+	log.Debugf("Successfully retrieved AWS API keys for account: %+v", account.Name)
+	return s3control.New(
+		session.Must(session.NewSession()),
+		aws.NewConfig().WithRegion("us-east-1").WithCredentials(creds))
+}
@@ -0,0 +1,212 @@
+package s3
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+const (
+	errCodeNoSuchCORSConfiguration    = "NoSuchCORSConfiguration"
+	errCodeNoSuchWebsiteConfiguration = "NoSuchWebsiteConfiguration"
+	errCodeNoSuchTagSet               = "NoSuchTagSet"
+)
+
+// writeMethods are the CORS methods that modify objects
+var writeMethods = []string{"PUT", "POST", "DELETE"}
+
+// Exposure represents the S3 exposure policy.
+// Bucket policy principals are compared against TrustedAccounts and OrganizationID.
+// Website hosting is allowed on buckets tagged with PublicWebsiteTagKey, and PublicWebsiteTagValue unless it is empty.
+type Exposure struct {
+	TrustedAccounts       []string
+	OrganizationID        string
+	PublicWebsiteTagKey   string
+	PublicWebsiteTagValue string
+}
+
+// CheckExposure returns all external accounts, organizations and service principals granted access in bucket policies,
+// CORS configurations allowing any origin to write, and website hosting on buckets not tagged as public websites
+func (ab *AB) CheckExposure(e Exposure) *RuleViolator {
+	vc := make(chan []RuleViolation)
+	defer close(vc)
+
+	rv := &RuleViolator{Account: ab.Account}
+	if len(ab.Buckets) == 0 {
+		return rv
+	}
+
+	for _, bucket := range ab.Buckets {
+		go func(bucket *s3.Bucket) {
+			vc <- ab.checkBucketExposure(*bucket.Name, e)
+		}(bucket)
+	}
+
+	for range ab.Buckets {
+		select {
+		case v := <-vc:
+			rv.Violations = append(rv.Violations, v...)
+		}
+	}
+	return rv
+}
+
+func (ab *AB) checkBucketExposure(bucket string, e Exposure) []RuleViolation {
+	client, err := ab.Regions.BucketClient(bucket)
+	if err != nil {
+		return nil
+	}
+
+	var violations []RuleViolation
+	add := func(rule, detail string) {
+		violations = append(violations, RuleViolation{Bucket: bucket, Rule: rule, Detail: detail})
+	}
+
+	out, err := getBucketPolicy(client, bucket, ab.Account)
+	if err == nil && out.Policy != nil {
+		doc, err := policy.Parse(*out.Policy)
+		if err != nil {
+			log.Warnf("Could not parse Bucket Policy of Bucket [%s] in Account [%s]: %+v", bucket, ab.Account.Name, err)
+		} else {
+			for _, v := range externalGrants(doc, e) {
+				add(v.Rule, v.Detail)
+			}
+		}
+	}
+
+	for _, methods := range publicWriteCORS(client, bucket, ab.Account) {
+		add("S3 Bucket CORS Allows Any Origin To Write", "allowed methods: "+strings.Join(methods, ","))
+	}
+
+	if hasWebsite(client, bucket, ab.Account) && !isPublicWebsite(client, bucket, ab.Account, e) {
+		add("S3 Bucket Website Not Tagged Public", "static website hosting is enabled")
+	}
+	return violations
+}
+
+// externalGrants returns the external accounts, organizations and service principals granted access by the bucket policy
+func externalGrants(doc *policy.Document, e Exposure) []RuleViolation {
+	var violations []RuleViolation
+	add := func(rule, principal string, stmt policy.Statement) {
+		violations = append(violations, RuleViolation{Rule: rule, Detail: principal + " granted " + grantedActions(stmt)})
+	}
+
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+		for _, principal := range stmt.Principal["AWS"] {
+			if principal == "*" {
+				for _, account := range conditionValues(stmt, "aws:PrincipalAccount") {
+					if !oaws.IsTrusted(account, e.TrustedAccounts) {
+						add("S3 Bucket External Account Access", account, stmt)
+					}
+				}
+				for _, org := range conditionValues(stmt, "aws:PrincipalOrgID") {
+					if org != e.OrganizationID {
+						add("S3 Bucket External Organization Access", org, stmt)
+					}
+				}
+				continue
+			}
+			if account := policy.AccountID(principal); account != "" && !oaws.IsTrusted(account, e.TrustedAccounts) {
+				add("S3 Bucket External Account Access", principal, stmt)
+			}
+		}
+		for _, service := range stmt.Principal["Service"] {
+			if !stmt.HasConditionKey("aws:SourceAccount") && !stmt.HasConditionKey("aws:SourceArn") {
+				service += " (without aws:SourceAccount or aws:SourceArn condition)"
+			}
+			add("S3 Bucket Service Principal Access", service, stmt)
+		}
+	}
+	return violations
+}
+
+// conditionValues returns the values tested against the condition key by any condition operator of the statement
+func conditionValues(stmt policy.Statement, key string) []string {
+	var values []string
+	for _, conditions := range stmt.Condition {
+		for k, v := range conditions {
+			if strings.EqualFold(k, key) {
+				values = append(values, v...)
+			}
+		}
+	}
+	return values
+}
+
+func grantedActions(stmt policy.Statement) string {
+	if len(stmt.NotAction) > 0 {
+		return "NotAction " + strings.Join(stmt.NotAction, ",")
+	}
+	return strings.Join(stmt.Action, ",")
+}
+
+// publicWriteCORS returns the write methods of every CORS rule allowing any origin
+func publicWriteCORS(client *s3.S3, bucket string, account oaws.Account) [][]string {
+	out, err := client.GetBucketCors(&s3.GetBucketCorsInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errCodeNoSuchCORSConfiguration {
+			log.Debugf("Could not retrieve CORS for Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+		}
+		return nil
+	}
+
+	var public [][]string
+	for _, rule := range out.CORSRules {
+		if !policy.Value(aws.StringValueSlice(rule.AllowedOrigins)).Contains("*") {
+			continue
+		}
+		var methods []string
+		for _, m := range aws.StringValueSlice(rule.AllowedMethods) {
+			for _, w := range writeMethods {
+				if strings.EqualFold(m, w) {
+					methods = append(methods, w)
+				}
+			}
+		}
+		if len(methods) > 0 {
+			public = append(public, methods)
+		}
+	}
+	return public
+}
+
+func hasWebsite(client *s3.S3, bucket string, account oaws.Account) bool {
+	_, err := client.GetBucketWebsite(&s3.GetBucketWebsiteInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errCodeNoSuchWebsiteConfiguration {
+			log.Debugf("Could not retrieve Website for Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+		}
+		return false
+	}
+	return true
+}
+
+func isPublicWebsite(client *s3.S3, bucket string, account oaws.Account, e Exposure) bool {
+	if e.PublicWebsiteTagKey == "" {
+		return false
+	}
+	out, err := client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != errCodeNoSuchTagSet {
+			log.Debugf("Could not retrieve Tags for Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+		}
+		return false
+	}
+	for _, tag := range out.TagSet {
+		if aws.StringValue(tag.Key) != e.PublicWebsiteTagKey {
+			continue
+		}
+		if e.PublicWebsiteTagValue == "" || strings.EqualFold(aws.StringValue(tag.Value), e.PublicWebsiteTagValue) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+	"github.com/petermbenjamin/orthrus/checker/secrets"
+)
+
+// DefaultSensitivePatterns are object key patterns that usually hold sensitive data.
+// Patterns are matched case-insensitively against the whole object key.
+var DefaultSensitivePatterns = []string{
+	"*.env",
+	"*id_rsa*",
+	"*.sql",
+	"*backup*",
+	"*.pem",
+}
+
+// Sampling represents the opt-in sampling of object keys in public buckets.
+// MaxKeys is the no. of object keys listed per bucket. Zero disables sampling.
+// Objects whose keys match any of the Patterns (DefaultSensitivePatterns if none) are reported.
+// If Download is set, up to MaxDownloads matching objects of at most MaxObjectBytes are downloaded and scanned with Detectors.
+type Sampling struct {
+	MaxKeys        int
+	Patterns       []string
+	Download       bool
+	MaxDownloads   int
+	MaxObjectBytes int64
+	Detectors      []secrets.Detector
+}
+
+// SensitiveObject represents an object of a public bucket whose key matches a sensitive pattern,
+// and the redacted secrets found in it if it was downloaded
+type SensitiveObject struct {
+	Key     string
+	Pattern string
+	Size    int64
+	Matches []secrets.Match
+}
+
+// sampleSensitiveObjects lists up to s.MaxKeys objects of the bucket and returns those whose keys match a sensitive pattern
+func sampleSensitiveObjects(client *s3.S3, bucket string, account oaws.Account, s Sampling) []SensitiveObject {
+	patterns := s.Patterns
+	if len(patterns) == 0 {
+		patterns = DefaultSensitivePatterns
+	}
+
+	var objects []SensitiveObject
+	listed, downloads := 0, 0
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket), MaxKeys: aws.Int64(int64(s.MaxKeys))}
+	err := client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range page.Contents {
+			if listed >= s.MaxKeys {
+				return false
+			}
+			listed++
+
+			key := aws.StringValue(o.Key)
+			pattern := matchingPattern(key, patterns)
+			if pattern == "" {
+				continue
+			}
+			so := SensitiveObject{Key: key, Pattern: pattern, Size: aws.Int64Value(o.Size)}
+			if s.Download && downloads < s.MaxDownloads && so.Size > 0 && so.Size <= s.MaxObjectBytes {
+				downloads++
+				so.Matches = scanObject(client, bucket, key, account, s)
+			}
+			objects = append(objects, so)
+		}
+		return listed < s.MaxKeys
+	})
+	if err != nil {
+		log.Debugf("Could not list Objects in Bucket [%s] in Account [%s]: %+v", bucket, account.Name, err)
+	}
+	return objects
+}
+
+func matchingPattern(key string, patterns []string) string {
+	for _, p := range patterns {
+		if policy.Match(p, key) {
+			return p
+		}
+	}
+	return ""
+}
+
+// scanObject downloads at most s.MaxObjectBytes of the object and runs the secret detectors over it
+func scanObject(client *s3.S3, bucket, key string, account oaws.Account, s Sampling) []secrets.Match {
+	if s.MaxObjectBytes <= 0 {
+		return nil
+	}
+	out, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", s.MaxObjectBytes-1)),
+	})
+	if err != nil {
+		log.Debugf("Could not download Object [%s] in Bucket [%s] in Account [%s]: %+v", key, bucket, account.Name, err)
+		return nil
+	}
+	defer out.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(out.Body, s.MaxObjectBytes))
+	if err != nil {
+		log.Debugf("Could not read Object [%s] in Bucket [%s] in Account [%s]: %+v", key, bucket, account.Name, err)
+		return nil
+	}
+	log.Debugf("Scanning %d bytes of Object [%s] in Bucket [%s] in Account [%s]", len(data), key, bucket, account.Name)
+	return secrets.Scan(data, s.Detectors)
+}
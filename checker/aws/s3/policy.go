@@ -0,0 +1,192 @@
+package s3
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+// RestrictingConditionKeys are condition keys that restrict an otherwise public grant
+// to known networks, VPC endpoints, organizations or principals
+var RestrictingConditionKeys = []string{
+	"aws:SourceIp",
+	"aws:SourceVpc",
+	"aws:SourceVpce",
+	"aws:SourceAccount",
+	"aws:SourceArn",
+	"aws:SourceOwner",
+	"aws:PrincipalOrgID",
+	"aws:PrincipalAccount",
+	"aws:PrincipalArn",
+	"aws:userid",
+	"aws:username",
+}
+
+// publicGrants returns the S3 actions a bucket policy grants to everyone.
+// Grants restricted by conditions on RestrictingConditionKeys, or denied on all their resources to everyone outside of
+// known networks, VPC endpoints, organizations or principals, are not public.
+func publicGrants(doc *policy.Document, bucket string) []string {
+	var denies []policy.Statement
+	for _, stmt := range doc.Statement {
+		if stmt.Effect == "Deny" && isPublicPrincipal(stmt) && deniesPublicAccess(stmt) {
+			denies = append(denies, stmt)
+		}
+	}
+
+	var grants []string
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != "Allow" || !isPublicPrincipal(stmt) || isRestricted(stmt) {
+			continue
+		}
+		if len(stmt.NotAction) > 0 {
+			if isDenied(denies, "s3:*", stmt) {
+				continue
+			}
+			log.Debugf("Bucket [%s] grants all actions except %v to everyone", bucket, stmt.NotAction)
+			grants = append(grants, "NotAction "+strings.Join(stmt.NotAction, ","))
+			continue
+		}
+		for _, action := range stmt.Action {
+			if isS3Action(action) && !isDenied(denies, action, stmt) {
+				log.Debugf("Bucket [%s] grants [%s] to everyone", bucket, action)
+				grants = append(grants, action)
+			}
+		}
+	}
+	return grants
+}
+
+// isPublicPrincipal returns true if the statement applies to everyone,
+// either through a wildcard principal or through NotPrincipal
+func isPublicPrincipal(stmt policy.Statement) bool {
+	if len(stmt.NotPrincipal) > 0 {
+		return true
+	}
+	for _, p := range stmt.Principal["AWS"] {
+		if p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRestricted returns true if any condition of an Allow statement limits it to specific values of a restricting key.
+// Negated operators (e.g. NotIpAddress), IfExists and ForAllValues operators, and wildcard or all-address values restrict nothing.
+func isRestricted(stmt policy.Statement) bool {
+	for operator, conditions := range stmt.Condition {
+		// IfExists operators also match requests without the key
+		negated, ok := restrictingOperator(operator)
+		if !ok || negated || strings.HasSuffix(strings.ToLower(operator), "ifexists") {
+			continue
+		}
+		for key, values := range conditions {
+			if isRestrictingKey(key) && restrictsValues(values) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deniesPublicAccess returns true if a Deny statement applies to every request except those with specific values
+// of a restricting key (e.g. "Deny unless aws:SourceVpce is vpce-1234"), or applies unconditionally
+func deniesPublicAccess(stmt policy.Statement) bool {
+	for operator, conditions := range stmt.Condition {
+		negated, ok := restrictingOperator(operator)
+		if !ok || !negated {
+			return false
+		}
+		for key, values := range conditions {
+			if !isRestrictingKey(key) || !restrictsValues(values) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDenied returns true if any of the denies covers the action pattern on all resources of the Allow statement
+func isDenied(denies []policy.Statement, action string, allow policy.Statement) bool {
+	resources := allow.Resource
+	if len(resources) == 0 {
+		// NotResource grants apply to all but some resources
+		resources = policy.Value{"*"}
+	}
+	for _, d := range denies {
+		if deniesAction(d, action) && deniesResources(d, resources) {
+			return true
+		}
+	}
+	return false
+}
+
+func deniesAction(d policy.Statement, action string) bool {
+	if len(d.NotAction) > 0 {
+		return !d.NotAction.Contains(action)
+	}
+	return d.Action.Contains(action)
+}
+
+// deniesResources returns true if the Deny statement applies to every resource matched by the resource patterns.
+// A NotResource Deny does not apply to resource patterns overlapping any of its exclusions.
+func deniesResources(d policy.Statement, resources policy.Value) bool {
+	for _, r := range resources {
+		if len(d.NotResource) > 0 {
+			for _, excluded := range d.NotResource {
+				if policy.Match(excluded, r) || policy.Match(r, excluded) {
+					return false
+				}
+			}
+			continue
+		}
+		if !d.Resource.Contains(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// restrictingOperator parses a string, ARN or IP address condition operator, and returns whether it is negated.
+// It returns false for operators that cannot restrict access to specific values, such as Null, Bool and ForAllValues operators.
+func restrictingOperator(operator string) (negated bool, ok bool) {
+	op := strings.ToLower(operator)
+	if strings.HasPrefix(op, "forallvalues:") {
+		return false, false
+	}
+	op = strings.TrimSuffix(strings.TrimPrefix(op, "foranyvalue:"), "ifexists")
+	switch op {
+	case "stringequals", "stringequalsignorecase", "stringlike", "arnequals", "arnlike", "ipaddress":
+		return false, true
+	case "stringnotequals", "stringnotequalsignorecase", "stringnotlike", "arnnotequals", "arnnotlike", "notipaddress":
+		return true, true
+	}
+	return false, false
+}
+
+func isRestrictingKey(key string) bool {
+	for _, k := range RestrictingConditionKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictsValues returns false if any value matches everything, such as a wildcard or an all-address CIDR block
+func restrictsValues(values policy.Value) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if strings.Trim(v, "*?") == "" || v == "0.0.0.0/0" || v == "::/0" {
+			return false
+		}
+	}
+	return true
+}
+
+// isS3Action returns true if the action pattern matches any S3 action (e.g. *, s3:*, s3:Get*)
+func isS3Action(action string) bool {
+	return action == "*" || strings.HasPrefix(strings.ToLower(action), "s3:")
+}
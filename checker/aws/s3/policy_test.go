@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+func TestPublicGrants(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "public read",
+			doc:  `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"}]}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "action array, wildcard principal in map, and non-S3 actions",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": {"AWS": ["*"]}, "Action": ["s3:Get*", "s3:ListBucket", "sqs:SendMessage"], "Resource": "*"}}`,
+			want: []string{"s3:Get*", "s3:ListBucket"},
+		},
+		{
+			name: "all statements are evaluated",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::111122223333:root"}, "Action": "s3:*", "Resource": "*"},
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:PutObject", "Resource": "*"}
+			]}`,
+			want: []string{"s3:PutObject"},
+		},
+		{
+			name: "NotPrincipal and NotAction",
+			doc:  `{"Statement": {"Effect": "Allow", "NotPrincipal": {"AWS": "arn:aws:iam::111122223333:root"}, "NotAction": "s3:DeleteBucket", "Resource": "*"}}`,
+			want: []string{"NotAction s3:DeleteBucket"},
+		},
+		{
+			name: "restricted to source IP",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"IpAddress": {"aws:SourceIp": "203.0.113.0/24"}}}}`,
+		},
+		{
+			name: "restricted to organization",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"StringEquals": {"aws:PrincipalOrgID": ["o-abc123"]}}}}`,
+		},
+		{
+			name: "NotIpAddress allows everyone else",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"NotIpAddress": {"aws:SourceIp": "203.0.113.0/24"}}}}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "all addresses",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8", "0.0.0.0/0"]}}}}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "wildcard principal ARN",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"StringLike": {"aws:PrincipalArn": "*"}}}}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "IfExists matches requests without the key",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"StringEqualsIfExists": {"aws:SourceVpce": "vpce-1234"}}}}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "non-restricting condition key",
+			doc:  `{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": true}}}}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "denied unless from VPC endpoint",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "*", "Condition": {"StringNotEquals": {"aws:SourceVpce": "vpce-1234"}}}
+			]}`,
+		},
+		{
+			name: "deny covering other actions",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:Put*", "Resource": "*", "Condition": {"NotIpAddress": {"aws:SourceIp": "203.0.113.0/24"}}}
+			]}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "deny on the granted objects",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": ["arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"]}
+			]}`,
+		},
+		{
+			name: "deny on a single prefix",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::bucket/private/*"}
+			]}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "deny excluding a prefix",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "NotResource": "arn:aws:s3:::bucket/public/*"}
+			]}`,
+			want: []string{"s3:GetObject"},
+		},
+		{
+			name: "deny only without TLS",
+			doc: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*"},
+				{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": false}}}
+			]}`,
+			want: []string{"s3:GetObject"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := policy.Parse(tt.doc)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := publicGrants(doc, "bucket"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("publicGrants() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
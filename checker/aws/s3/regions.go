@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+)
+
+// defaultRegion is the region of buckets without a location constraint, and the region used for account-wide S3 calls
+const defaultRegion = "us-east-1"
+
+var (
+	regionsMu sync.Mutex
+	regions   = make(map[string]*Regions)
+)
+
+// Regions caches the region of every bucket of an account and one S3 client per region,
+// so that all S3 checks of an account share them
+type Regions struct {
+	account oaws.Account
+
+	mu      sync.Mutex
+	buckets map[string]string
+	clients map[string]*s3.S3
+}
+
+// RegionsFor returns the region cache of the account
+func RegionsFor(account oaws.Account) *Regions {
+	regionsMu.Lock()
+	defer regionsMu.Unlock()
+	r, ok := regions[account.Name]
+	if !ok {
+		r = &Regions{
+			account: account,
+			buckets: make(map[string]string),
+			clients: make(map[string]*s3.S3),
+		}
+		regions[account.Name] = r
+	}
+	return r
+}
+
+// Client returns the S3 client of the region, creating it on first use
+func (r *Regions) Client(region string) *s3.S3 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clients[region]
+	if !ok {
+		c = ClientWithRegion(r.account, region)
+		r.clients[region] = c
+	}
+	return c
+}
+
+// Region returns the region of the bucket, retrieving its location constraint on first use
+func (r *Regions) Region(bucket string) (string, error) {
+	r.mu.Lock()
+	region, ok := r.buckets[bucket]
+	r.mu.Unlock()
+	if ok {
+		return region, nil
+	}
+
+	out, err := r.Client(defaultRegion).GetBucketLocation(&s3.GetBucketLocationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		log.Debugf("Could not retrieve Region for Bucket [%s] in Account [%s]: %+v", bucket, r.account.Name, err)
+		return "", err
+	}
+	region = locationRegion(aws.StringValue(out.LocationConstraint))
+	log.Debugf("Bucket [%s] was found in Region [%s]", bucket, region)
+
+	r.mu.Lock()
+	r.buckets[bucket] = region
+	r.mu.Unlock()
+	return region, nil
+}
+
+// BucketClient returns the S3 client of the bucket's region
+func (r *Regions) BucketClient(bucket string) (*s3.S3, error) {
+	region, err := r.Region(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return r.Client(region), nil
+}
+
+// locationRegion converts a bucket location constraint to a region.
+// Buckets in us-east-1 have no location constraint, and "EU" is a legacy alias of eu-west-1.
+func locationRegion(constraint string) string {
+	switch constraint {
+	case "":
+		return defaultRegion
+	case s3.BucketLocationConstraintEu:
+		return "eu-west-1"
+	}
+	return constraint
+}
@@ -0,0 +1,195 @@
+package s3
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/policy"
+)
+
+const (
+	errCodeNoSuchEncryptionConfiguration = "ServerSideEncryptionConfigurationNotFoundError"
+	errCodeNoSuchBucketPolicy            = "NoSuchBucketPolicy"
+)
+
+// Rules represents the S3 baseline rules. Each rule is enabled individually.
+// KMSOnly requires default encryption to use KMS keys, and implies Encryption.
+// MFADelete applies to buckets whose names match any of the SensitiveBuckets patterns (e.g. "*-audit-logs"),
+// or to all buckets if none are configured.
+type Rules struct {
+	Encryption       bool
+	KMSOnly          bool
+	Versioning       bool
+	Logging          bool
+	MFADelete        bool
+	SensitiveBuckets []string
+	SecureTransport  bool
+}
+
+// RuleViolator holds a slice of S3 baseline rule violations per account.
+type RuleViolator struct {
+	Account    oaws.Account
+	Violations []RuleViolation
+}
+
+// RuleViolation represents a bucket violating an S3 baseline rule
+type RuleViolation struct {
+	Bucket string
+	Rule   string
+	Detail string
+}
+
+// CheckRules returns all buckets for a given account that violate the enabled baseline rules
+func (ab *AB) CheckRules(r Rules) *RuleViolator {
+	vc := make(chan []RuleViolation)
+	defer close(vc)
+
+	rv := &RuleViolator{Account: ab.Account}
+	if len(ab.Buckets) == 0 {
+		return rv
+	}
+
+	for _, bucket := range ab.Buckets {
+		go func(bucket *s3.Bucket) {
+			vc <- ab.checkBucketRules(*bucket.Name, r)
+		}(bucket)
+	}
+
+	for range ab.Buckets {
+		select {
+		case v := <-vc:
+			rv.Violations = append(rv.Violations, v...)
+		}
+	}
+	return rv
+}
+
+func (ab *AB) checkBucketRules(bucket string, r Rules) []RuleViolation {
+	client, err := ab.Regions.BucketClient(bucket)
+	if err != nil {
+		return nil
+	}
+
+	var violations []RuleViolation
+	add := func(rule, detail string) {
+		violations = append(violations, RuleViolation{Bucket: bucket, Rule: rule, Detail: detail})
+	}
+
+	if r.Encryption || r.KMSOnly {
+		if rule, detail := checkEncryption(client, bucket, r.KMSOnly); rule != "" {
+			add(rule, detail)
+		}
+	}
+	if r.Versioning || (r.MFADelete && isSensitive(bucket, r.SensitiveBuckets)) {
+		versioning, err := client.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			log.Debugf("Could not retrieve Versioning for Bucket [%s] in Account [%s]: %+v", bucket, ab.Account.Name, err)
+		} else {
+			if r.Versioning && aws.StringValue(versioning.Status) != s3.BucketVersioningStatusEnabled {
+				add("S3 Bucket Versioning Disabled", "versioning status: "+statusOrNone(versioning.Status))
+			}
+			if r.MFADelete && isSensitive(bucket, r.SensitiveBuckets) && aws.StringValue(versioning.MFADelete) != s3.MFADeleteStatusEnabled {
+				add("S3 Bucket MFA Delete Disabled", "MFA delete status: "+statusOrNone(versioning.MFADelete))
+			}
+		}
+	}
+	if r.Logging {
+		logging, err := client.GetBucketLogging(&s3.GetBucketLoggingInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			log.Debugf("Could not retrieve Logging for Bucket [%s] in Account [%s]: %+v", bucket, ab.Account.Name, err)
+		} else if logging.LoggingEnabled == nil {
+			add("S3 Bucket Access Logging Disabled", "no server access logging target")
+		}
+	}
+	if r.SecureTransport {
+		enforced, err := enforcesSecureTransport(client, bucket, ab.Account)
+		if err != nil {
+			log.Errorf("Could not check TLS enforcement for Bucket [%s] in Account [%s]: %+v", bucket, ab.Account.Name, err)
+		} else if !enforced {
+			add("S3 Bucket Allows Insecure Transport", "bucket policy does not deny requests where aws:SecureTransport is false")
+		}
+	}
+	return violations
+}
+
+// checkEncryption returns the violated rule and its detail, or an empty rule if default encryption complies
+func checkEncryption(client *s3.S3, bucket string, kmsOnly bool) (string, string) {
+	out, err := client.GetBucketEncryption(&s3.GetBucketEncryptionInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeNoSuchEncryptionConfiguration {
+			return "S3 Bucket Not Encrypted", "no default encryption configuration"
+		}
+		log.Debugf("Could not retrieve Encryption for Bucket [%s]: %+v", bucket, err)
+		return "", ""
+	}
+	if !kmsOnly || out.ServerSideEncryptionConfiguration == nil {
+		return "", ""
+	}
+	for _, rule := range out.ServerSideEncryptionConfiguration.Rules {
+		if rule.ApplyServerSideEncryptionByDefault == nil {
+			continue
+		}
+		algorithm := aws.StringValue(rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm)
+		if !strings.HasPrefix(algorithm, s3.ServerSideEncryptionAwsKms) {
+			return "S3 Bucket Not Encrypted With KMS", "default encryption algorithm: " + algorithm
+		}
+	}
+	return "", ""
+}
+
+// enforcesSecureTransport returns true if the bucket policy denies requests made without TLS,
+// and false if the bucket has no policy. It returns an error if the policy could not be retrieved or parsed.
+func enforcesSecureTransport(client *s3.S3, bucket string, account oaws.Account) (bool, error) {
+	out, err := getBucketPolicy(client, bucket, account)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeNoSuchBucketPolicy {
+			return false, nil
+		}
+		return false, err
+	}
+	if out.Policy == nil {
+		return false, nil
+	}
+	doc, err := policy.Parse(*out.Policy)
+	if err != nil {
+		return false, err
+	}
+	for _, stmt := range doc.Statement {
+		if stmt.Effect != "Deny" || !isPublicPrincipal(stmt) || !deniesAllS3Actions(stmt) {
+			continue
+		}
+		for operator, conditions := range stmt.Condition {
+			if !strings.EqualFold(operator, "Bool") {
+				continue
+			}
+			for key, values := range conditions {
+				if strings.EqualFold(key, "aws:SecureTransport") && values.Contains("false") {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+func deniesAllS3Actions(stmt policy.Statement) bool {
+	return len(stmt.NotAction) == 0 && stmt.Action.Contains("s3:*")
+}
+
+func isSensitive(bucket string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return policy.Value(patterns).Contains(bucket)
+}
+
+func statusOrNone(status *string) string {
+	if s := aws.StringValue(status); s != "" {
+		return s
+	}
+	return "none"
+}
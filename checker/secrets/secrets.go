@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Detector finds secrets in a single line of text
+type Detector interface {
+	// Name returns the name of the detector, used to enable it in the configuration file.
+	Name() string
+	// Detect returns the secrets found in a line of text.
+	Detect(line string) []string
+}
+
+// Match represents a secret found by a detector.
+// Secret is redacted and safe to log.
+type Match struct {
+	Detector string
+	Line     int
+	Secret   string
+}
+
+var detectors = []Detector{
+	&regexDetector{
+		name: "aws-access-key",
+		re:   regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`),
+	},
+	&regexDetector{
+		name: "aws-secret-key",
+		re:   regexp.MustCompile(`(?i)aws.{0,20}(?:secret|private).{0,20}?['"=:\s]([A-Za-z0-9/+]{40})\b`),
+	},
+	&regexDetector{
+		name: "private-key",
+		re:   regexp.MustCompile(`-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	&regexDetector{
+		name: "password-in-url",
+		re:   regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s:@]+)@`),
+	},
+	&entropyDetector{
+		name:      "high-entropy-token",
+		minLength: 20,
+		threshold: 4.5,
+	},
+}
+
+// Register adds a detector to the set of known detectors
+func Register(d Detector) {
+	detectors = append(detectors, d)
+}
+
+// Detectors returns the known detectors with the given names.
+// If no names are given, all known detectors are returned.
+func Detectors(names ...string) []Detector {
+	if len(names) == 0 {
+		return detectors
+	}
+	var ds []Detector
+	for _, d := range detectors {
+		for _, name := range names {
+			if d.Name() == name {
+				ds = append(ds, d)
+			}
+		}
+	}
+	return ds
+}
+
+// Scan runs the detectors over data and returns all redacted matches.
+// A secret found by more than one detector on the same line is only reported by the first.
+func Scan(data []byte, ds []Detector) []Match {
+	var matches []Match
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		seen := make(map[string]bool)
+		for _, d := range ds {
+			for _, secret := range d.Detect(scanner.Text()) {
+				if seen[secret] {
+					continue
+				}
+				seen[secret] = true
+				matches = append(matches, Match{Detector: d.Name(), Line: n, Secret: Redact(secret)})
+			}
+		}
+	}
+	return matches
+}
+
+// Redact masks all but the first 4 characters of a secret
+func Redact(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", len(secret)-4)
+}
+
+type regexDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func (d *regexDetector) Name() string {
+	return d.name
+}
+
+// Detect returns the first capture group of each match if there is one, or the whole match otherwise
+func (d *regexDetector) Detect(line string) []string {
+	var found []string
+	for _, m := range d.re.FindAllStringSubmatch(line, -1) {
+		if len(m) > 1 && m[1] != "" {
+			found = append(found, m[1])
+			continue
+		}
+		found = append(found, m[0])
+	}
+	return found
+}
+
+type entropyDetector struct {
+	name      string
+	minLength int
+	threshold float64
+}
+
+var tokenRe = regexp.MustCompile(`[A-Za-z0-9+/]+={0,2}`)
+
+func (d *entropyDetector) Name() string {
+	return d.name
+}
+
+func (d *entropyDetector) Detect(line string) []string {
+	var found []string
+	for _, token := range tokenRe.FindAllString(line, -1) {
+		if len(token) >= d.minLength && entropy(token) >= d.threshold {
+			found = append(found, token)
+		}
+	}
+	return found
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	freq := make(map[rune]float64)
+	for _, r := range s {
+		freq[r]++
+	}
+	var e float64
+	l := float64(len(s))
+	for _, f := range freq {
+		p := f / l
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -7,13 +7,30 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
 	homedir "github.com/mitchellh/go-homedir"
 	oaws "github.com/petermbenjamin/orthrus/checker/aws"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/eip"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/hygiene"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/images"
 	"github.com/petermbenjamin/orthrus/checker/aws/ec2/instances"
 	"github.com/petermbenjamin/orthrus/checker/aws/ec2/sg"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/userdata"
+	"github.com/petermbenjamin/orthrus/checker/aws/ec2/vpc"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/certs"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/grants"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/groups"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/keys"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/lastused"
 	"github.com/petermbenjamin/orthrus/checker/aws/iam/mfa"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/password"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/privesc"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/root"
+	"github.com/petermbenjamin/orthrus/checker/aws/iam/trust"
 	"github.com/petermbenjamin/orthrus/checker/aws/iam/users"
+	"github.com/petermbenjamin/orthrus/checker/aws/route53"
 	"github.com/petermbenjamin/orthrus/checker/aws/s3"
+	"github.com/petermbenjamin/orthrus/checker/secrets"
 	"github.com/spf13/viper"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -42,9 +59,15 @@ var (
 		}).Bool()
 
 	// ec2 command
-	ec2Cmd = app.Command("ec2", "Check EC2 Policies.").Alias("e")
-	iCmd   = ec2Cmd.Command("instances", "Check EC2 Instances").Alias("i")
-	sgCmd  = ec2Cmd.Command("sg", "Check Security Group").Alias("s")
+	ec2Cmd  = app.Command("ec2", "Check EC2 Policies.").Alias("e")
+	iCmd    = ec2Cmd.Command("instances", "Check EC2 Instances").Alias("i")
+	sgCmd   = ec2Cmd.Command("sg", "Check Security Group").Alias("s")
+	amiCmd  = ec2Cmd.Command("ami", "Check AMI Launch Permissions").Alias("a")
+	udCmd   = ec2Cmd.Command("userdata", "Scan EC2 Instance User Data for Secrets (opt-in)").Alias("u")
+	hygCmd  = ec2Cmd.Command("hygiene", "Check EC2 Instance Hygiene").Alias("h")
+	eipCmd  = ec2Cmd.Command("eip", "Check Elastic IPs").Alias("ip")
+	vpcCmd  = ec2Cmd.Command("vpc", "Check VPC Flow Logs, Default VPCs and Peering Connections").Alias("v")
+	r53Flag = eipCmd.Flag("route53", "Report Route 53 A records pointing at EC2 IPs not owned by any configured account.").Bool()
 
 	// iam command
 	iamCmd          = app.Command("iam", "Check IAM Policies.").Alias("i")
@@ -52,9 +75,21 @@ var (
 	mfaMaxDaysFlag  = mfaCmd.Flag("mfa-max-days", "Max no. of days users are allowed without MFA.").Int()
 	userCmd         = iamCmd.Command("user", "Check IAM User Policies").Alias("u")
 	userMaxDaysFlag = userCmd.Flag("user-max-days", "Max no. of days users are inactive.").Int()
+	keysCmd         = iamCmd.Command("keys", "Check IAM Access Key Policies").Alias("k")
+	rootCmd         = iamCmd.Command("root", "Check Root Account Policies").Alias("r")
+	passwordCmd     = iamCmd.Command("password", "Check IAM Account Password Policy").Alias("p")
+	grantsCmd       = iamCmd.Command("grants", "Check IAM Policies for Admin-Equivalent Grants").Alias("g")
+	trustCmd        = iamCmd.Command("trust", "Check IAM Role Trust Policies").Alias("t")
+	unusedCmd       = iamCmd.Command("unused", "Check Unused IAM Roles and Permissions").Alias("n")
+	privescCmd      = iamCmd.Command("privesc", "Check IAM Privilege Escalation Paths").Alias("e")
+	groupsCmd       = iamCmd.Command("groups", "Check IAM Direct Policy Attachments and Groups").Alias("gr")
+	certsCmd        = iamCmd.Command("certs", "Check IAM Server Certificates, SSH Keys and Signing Certificates").Alias("c")
 
 	// s3 command
-	s3Cmd = app.Command("s3", "Check S3 Policies.")
+	s3Cmd         = app.Command("s3", "Check S3 Policies.")
+	s3PublicCmd   = s3Cmd.Command("public", "Check Public S3 Buckets and Block Public Access").Default().Alias("p")
+	s3BaselineCmd = s3Cmd.Command("baseline", "Check S3 Encryption, Versioning, Logging, MFA Delete and TLS Enforcement").Alias("b")
+	s3ExposureCmd = s3Cmd.Command("exposure", "Check S3 Cross-Account Access, CORS and Website Hosting").Alias("e")
 )
 
 func init() {
@@ -81,22 +116,29 @@ func main() {
 		for _, account := range accounts {
 			instances := instances.List(account, regions).CheckPolicy()
 			for _, e := range instances.Group {
-				if len(e.Instances) > 0 {
-					for _, i := range e.Instances {
-						if i.PublicIpAddress != nil {
-
-							logrus.WithFields(logrus.Fields{
-								"AccountName":   account.Name,
-								"AccountNumber": account.Number,
-								"Region":        e.Region,
-								"Instance-ID":   *i.InstanceId,
-								"Instance-IP":   *i.PublicIpAddress,
-								// TODO: Compare Instance SGs with Security Group policies to only report public instances with permissive SG rules
-								// "Instance-SG":   i.SecurityGroups,
-							}).Warnln("Public Instance IP")
-
-						}
+				for _, i := range e.Instances {
+					fields := logrus.Fields{
+						"AccountName":   account.Name,
+						"AccountNumber": account.Number,
+						"Region":        e.Region,
+						"Instance-ID":   *i.InstanceId,
+						"Instance-IP":   *i.PublicIpAddress,
+						// TODO: Compare Instance SGs with Security Group policies to only report public instances with permissive SG rules
+						// "Instance-SG":   i.SecurityGroups,
 					}
+					p, ok := e.Profiles[*i.InstanceId]
+					if !ok {
+						logrus.WithFields(fields).Warnln("Public Instance IP")
+						continue
+					}
+					fields["Instance-Profile"] = p.Arn
+					fields["Roles"] = p.Roles
+					if len(p.BroadPermissions) > 0 {
+						fields["Permissions"] = p.BroadPermissions
+						logrus.WithFields(fields).Errorln("Public Instance IP with Privileged Instance Profile")
+						continue
+					}
+					logrus.WithFields(fields).Warnln("Public Instance IP")
 				}
 			}
 		}
@@ -120,18 +162,156 @@ func main() {
 			}
 		}
 
+	case amiCmd.FullCommand():
+		for _, account := range accounts {
+			violations := images.List(account, regions).CheckPolicy(trustedAccounts())
+			for _, g := range violations.Group {
+				for _, i := range g.Images {
+					fields := logrus.Fields{
+						"AccountName":   violations.Account.Name,
+						"AccountNumber": violations.Account.Number,
+						"Region":        g.Region,
+						"Image-ID":      *i.ImageId,
+						"Image-Name":    aws.StringValue(i.Name),
+					}
+					if i.Public {
+						logrus.WithFields(fields).Warnln("Public AMI")
+					}
+					if len(i.SharedWith) > 0 {
+						fields["SharedWith"] = i.SharedWith
+						logrus.WithFields(fields).Warnln("AMI Shared Outside Organization")
+					}
+				}
+			}
+		}
+
+	case udCmd.FullCommand():
+		detectors := secrets.Detectors(userDataDetectors()...)
+		for _, account := range accounts {
+			violations := userdata.List(instances.List(account, regions)).CheckPolicy(detectors)
+			for _, g := range violations.Group {
+				for _, ud := range g.UserData {
+					for _, m := range ud.Matches {
+						logrus.WithFields(logrus.Fields{
+							"AccountName":   violations.Account.Name,
+							"AccountNumber": violations.Account.Number,
+							"Region":        g.Region,
+							"Instance-ID":   ud.InstanceID,
+							"Detector":      m.Detector,
+							"Line":          m.Line,
+							"Secret":        m.Secret,
+						}).Warnln("Secret in Instance User Data")
+					}
+				}
+			}
+		}
+
+	case hygCmd.FullCommand():
+		for _, account := range accounts {
+			violations := hygiene.List(instances.List(account, regions)).CheckPolicy(instancePolicy())
+			for _, g := range violations.Group {
+				for _, v := range g.Violations {
+					logrus.WithFields(logrus.Fields{
+						"AccountName":   violations.Account.Name,
+						"AccountNumber": violations.Account.Number,
+						"Region":        g.Region,
+						"Instance-ID":   *v.Instance.InstanceId,
+						"Detail":        v.Detail,
+					}).Warnln(v.Rule)
+				}
+			}
+		}
+
+	case eipCmd.FullCommand():
+		var owned []string
+		for _, account := range accounts {
+			addresses := eip.List(account, regions)
+			owned = append(owned, addresses.IPs()...)
+			violations := addresses.CheckPolicy()
+			for _, g := range violations.Group {
+				for _, a := range g.Addresses {
+					logrus.WithFields(logrus.Fields{
+						"AccountName":   violations.Account.Name,
+						"AccountNumber": violations.Account.Number,
+						"Region":        g.Region,
+						"Elastic-IP":    *a.PublicIp,
+					}).Warnln("Unassociated Elastic IP")
+				}
+			}
+		}
+		if !*r53Flag {
+			break
+		}
+
+		for _, account := range accounts {
+			for _, g := range instances.List(account, regions).Group {
+				for _, i := range g.Instances {
+					if i.PublicIpAddress != nil {
+						owned = append(owned, *i.PublicIpAddress)
+					}
+				}
+			}
+		}
+		ranges, err := oaws.IPRanges(oaws.IPRangesServiceEC2)
+		if err != nil {
+			logrus.WithField("file", "main.go").Errorf("could not retrieve EC2 IP ranges: %v", err)
+			break
+		}
+		for _, account := range accounts {
+			violations := route53.List(account).CheckPolicy(owned, ranges)
+			for _, r := range violations.Records {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"Zone":          r.Zone,
+					"Record":        r.Name,
+					"IP":            r.IP,
+				}).Warnln("Dangling DNS Record")
+			}
+		}
+
+	case vpcCmd.FullCommand():
+		for _, account := range accounts {
+			violations := vpc.List(account, regions).CheckPolicy(trustedAccounts())
+			for _, g := range violations.Group {
+				fields := logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"Region":        g.Region,
+				}
+				for _, v := range g.NoFlowLogs {
+					logrus.WithFields(fields).WithField("VPC-ID", *v.VpcId).Warnln("VPC without Flow Logs")
+				}
+				for _, v := range g.DefaultVPCs {
+					logrus.WithFields(fields).WithField("VPC-ID", *v.VpcId).Warnln("Default VPC in Use")
+				}
+				for _, p := range g.ExternalPeerings {
+					logrus.WithFields(fields).WithFields(logrus.Fields{
+						"Peering-ID":        *p.VpcPeeringConnectionId,
+						"Accepter-Account":  vpc.OwnerID(p.AccepterVpcInfo),
+						"Requester-Account": vpc.OwnerID(p.RequesterVpcInfo),
+					}).Warnln("VPC Peering Outside Organization")
+				}
+			}
+		}
+
 	case mfaCmd.FullCommand():
 		for _, account := range accounts {
-			violations := mfa.List(account).CheckPolicy(mfaMaxDays())
-			for _, v := range violations.Users {
-				y, m, d := v.CreateDate.Date()
+			violations := mfa.List(account).CheckPolicy(mfaPolicy())
+			for _, v := range violations.Violations {
+				createDate := ""
+				if v.User.UserCreationTime != nil {
+					y, m, d := v.User.UserCreationTime.Date()
+					createDate = fmt.Sprintf("%d-%d-%d", y, time.Month(m), d)
+				}
 
 				logrus.WithFields(logrus.Fields{
 					"AccountName":   violations.Account.Name,
 					"AccountNumber": violations.Account.Number,
-					"UserName":      *v.UserName,
-					"CreateDate":    fmt.Sprintf("%d-%d-%d", y, time.Month(m), d),
-				}).Warnln("Disabled MFA")
+					"UserName":      v.User.User,
+					"CreateDate":    createDate,
+					"MFADevices":    v.User.DeviceTypes(),
+				}).Warnln(v.Rule)
 
 			}
 		}
@@ -140,29 +320,262 @@ func main() {
 		for _, account := range accounts {
 			violations := users.List(account).CheckPolicy(userMaxDays())
 			for _, uv := range violations.Users {
-				y, m, d := uv.PasswordLastUsed.Date()
+				lastUsed := "never"
+				if uv.LastUsed != nil {
+					y, m, d := uv.LastUsed.Date()
+					lastUsed = fmt.Sprintf("%d-%d-%d", y, time.Month(m), d)
+				}
 
 				logrus.WithFields(logrus.Fields{
 					"AccountName":   violations.Account.Name,
 					"AccountNumber": violations.Account.Number,
-					"UserName":      *uv.UserName,
-					"LogonDate":     fmt.Sprintf("%d-%d-%d", y, time.Month(m), d),
+					"UserName":      uv.User,
+					"LastUsed":      lastUsed,
+					"Credential":    uv.Credential,
 				}).Warnln("Inactive User")
 
 			}
 		}
 
-	case s3Cmd.FullCommand():
+	case keysCmd.FullCommand():
+		for _, account := range accounts {
+			violations := keys.List(account).CheckPolicy(keysPolicy())
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"UserName":      v.UserName,
+					"AccessKeyID":   v.AccessKeyID,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
+
+	case rootCmd.FullCommand():
 		for _, account := range accounts {
-			violations := s3.List(account).CheckPolicy()
+			violations := root.List(account).CheckPolicy(rootPolicy())
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
+
+	case passwordCmd.FullCommand():
+		for _, account := range accounts {
+			violations := password.List(account).CheckPolicy(passwordPolicy())
+			fields := logrus.Fields{
+				"AccountName":   violations.Account.Name,
+				"AccountNumber": violations.Account.Number,
+			}
+			if violations.Err != nil {
+				logrus.WithFields(fields).WithField("Error", violations.Err).Errorln("Could not check Password Policy")
+				continue
+			}
+			if violations.Missing {
+				logrus.WithFields(fields).Errorln("No Password Policy")
+				continue
+			}
+			for _, d := range violations.Deviations {
+				logrus.WithFields(fields).WithFields(logrus.Fields{
+					"Setting": d.Setting,
+					"Want":    d.Want,
+					"Got":     d.Got,
+				}).Warnln("Password Policy Deviation")
+			}
+		}
+
+	case grantsCmd.FullCommand():
+		for _, account := range accounts {
+			violations := grants.List(account).CheckPolicy()
+			for _, v := range violations.Violations {
+				for _, f := range v.Findings {
+					fields := logrus.Fields{
+						"AccountName":   violations.Account.Name,
+						"AccountNumber": violations.Account.Number,
+						"PolicyType":    v.Policy.Type,
+						"PolicyName":    v.Policy.Name,
+						"Entity":        v.Policy.Entity,
+						"Sid":           f.Sid,
+						"Grant":         f.Reason,
+					}
+					if f.Partial {
+						logrus.WithFields(fields).Infoln("Partially Admin-Equivalent Policy")
+						continue
+					}
+					logrus.WithFields(fields).Warnln("Admin-Equivalent Policy")
+				}
+			}
+		}
+
+	case trustCmd.FullCommand():
+		for _, account := range accounts {
+			violations := trust.List(account).CheckPolicy(trustedAccounts())
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"RoleName":      v.Role.Name,
+					"Principal":     v.Principal,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
+
+	case unusedCmd.FullCommand():
+		for _, account := range accounts {
+			violations := lastused.List(account).CheckPolicy(unusedMaxDays())
+			fields := logrus.Fields{
+				"AccountName":   violations.Account.Name,
+				"AccountNumber": violations.Account.Number,
+			}
+			for _, r := range violations.UnusedRoles {
+				lastUsed := "never"
+				if r.LastUsed != nil {
+					y, m, d := r.LastUsed.Date()
+					lastUsed = fmt.Sprintf("%d-%d-%d", y, time.Month(m), d)
+				}
+				logrus.WithFields(fields).WithFields(logrus.Fields{
+					"RoleName": r.Name,
+					"LastUsed": lastUsed,
+				}).Warnln("Unused Role")
+			}
+			for _, us := range violations.UnusedServices {
+				logrus.WithFields(fields).WithFields(logrus.Fields{
+					"PrincipalType": us.Principal.Type,
+					"PrincipalName": us.Principal.Name,
+					"Services":      us.Services,
+				}).Warnln("Unused Service Permissions")
+			}
+			for _, p := range violations.Unknown {
+				logrus.WithFields(fields).WithFields(logrus.Fields{
+					"PrincipalType": p.Type,
+					"PrincipalName": p.Name,
+					"Error":         p.Err,
+				}).Warnln("Unknown Service Permissions Usage")
+			}
+		}
+
+	case privescCmd.FullCommand():
+		for _, account := range accounts {
+			violations := privesc.List(account).CheckPolicy()
+			for _, v := range violations.Violations {
+				path := v.Principal.Node()
+				for _, e := range v.Path {
+					path += fmt.Sprintf(" --[%s]--> %s", e.Via, e.To)
+				}
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"PrincipalType": v.Principal.Type,
+					"PrincipalName": v.Principal.Name,
+					"Path":          path,
+				}).Errorln("Privilege Escalation Path")
+			}
+		}
+
+	case groupsCmd.FullCommand():
+		for _, account := range accounts {
+			violations := groups.List(account).CheckPolicy()
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"EntityType":    v.Entity.Type,
+					"EntityName":    v.Entity.Name,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
+
+	case certsCmd.FullCommand():
+		for _, account := range accounts {
+			violations := certs.List(account).CheckPolicy(certsPolicy())
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"UserName":      v.UserName,
+					"ID":            v.ID,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
+
+	case s3PublicCmd.FullCommand():
+		for _, account := range accounts {
+			violations := s3.List(account).CheckPolicy(s3Policy())
 			for _, b := range violations.Buckets {
+				fields := logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"BucketName":    b.Name,
+				}
+				if len(b.PolicyGrants) > 0 && b.BlockPublicAccess.BlockPublicPolicy {
+					logrus.WithFields(fields).WithField("Grants", b.PolicyGrants).Warnln("Public S3 Bucket (Blocked for New Policies)")
+				} else if len(b.PolicyGrants) > 0 {
+					logrus.WithFields(fields).WithField("Grants", b.PolicyGrants).Warnln("Public S3 Bucket")
+				}
+				if len(b.ACLGrants) > 0 {
+					logrus.WithFields(fields).WithField("Grants", b.ACLGrants).Warnln("Public S3 Bucket ACL")
+				}
+				for key, grants := range b.ObjectACLGrants {
+					logrus.WithFields(fields).WithFields(logrus.Fields{
+						"ObjectKey": key,
+						"Grants":    grants,
+					}).Warnln("Public S3 Object ACL")
+				}
+				for _, o := range b.SensitiveObjects {
+					logrus.WithFields(fields).WithFields(logrus.Fields{
+						"ObjectKey": o.Key,
+						"Pattern":   o.Pattern,
+						"Size":      o.Size,
+					}).Errorln("Sensitive Object in Public S3 Bucket")
+					for _, m := range o.Matches {
+						logrus.WithFields(fields).WithFields(logrus.Fields{
+							"ObjectKey": o.Key,
+							"Detector":  m.Detector,
+							"Line":      m.Line,
+							"Secret":    m.Secret,
+						}).Errorln("Secret in Public S3 Object")
+					}
+				}
+			}
+			for _, b := range violations.Unblocked {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"BucketName":    b.Name,
+					"Disabled":      b.Disabled,
+				}).Warnln("S3 Block Public Access Disabled")
+			}
+		}
 
+	case s3BaselineCmd.FullCommand():
+		for _, account := range accounts {
+			violations := s3.List(account).CheckRules(s3Rules())
+			for _, v := range violations.Violations {
 				logrus.WithFields(logrus.Fields{
 					"AccountName":   violations.Account.Name,
 					"AccountNumber": violations.Account.Number,
-					"BucketName":    b,
-				}).Warnln("Public S3 Bucket")
+					"BucketName":    v.Bucket,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
+			}
+		}
 
+	case s3ExposureCmd.FullCommand():
+		for _, account := range accounts {
+			violations := s3.List(account).CheckExposure(s3Exposure())
+			for _, v := range violations.Violations {
+				logrus.WithFields(logrus.Fields{
+					"AccountName":   violations.Account.Name,
+					"AccountNumber": violations.Account.Number,
+					"BucketName":    v.Bucket,
+					"Detail":        v.Detail,
+				}).Warnln(v.Rule)
 			}
 		}
 	}
@@ -193,18 +606,117 @@ func getAccounts() []oaws.Account {
 	return accounts
 }
 
-func mfaMaxDays() int {
-	return viper.GetInt("aws.iam.mfa.policies.max_days")
+func mfaPolicy() mfa.Policy {
+	return mfa.Policy{
+		MaxDays:         viper.GetInt("aws.iam.mfa.policies.max_days"),
+		RequireHardware: viper.GetBool("aws.iam.mfa.policies.require_hardware"),
+	}
 }
 
 func userMaxDays() int {
 	return viper.GetInt("aws.iam.user.policies.max_days")
 }
 
+func passwordPolicy() password.Policy {
+	return password.Policy{
+		MinimumLength:    viper.GetInt("aws.iam.password.policies.minimum_length"),
+		RequireUppercase: viper.GetBool("aws.iam.password.policies.require_uppercase"),
+		RequireLowercase: viper.GetBool("aws.iam.password.policies.require_lowercase"),
+		RequireNumbers:   viper.GetBool("aws.iam.password.policies.require_numbers"),
+		RequireSymbols:   viper.GetBool("aws.iam.password.policies.require_symbols"),
+		MaxAgeDays:       viper.GetInt("aws.iam.password.policies.max_age_days"),
+		ReusePrevention:  viper.GetInt("aws.iam.password.policies.reuse_prevention"),
+		HardExpiry:       viper.GetBool("aws.iam.password.policies.hard_expiry"),
+	}
+}
+
+func rootPolicy() root.Policy {
+	return root.Policy{
+		MaxDays:            viper.GetInt("aws.iam.root.policies.max_days"),
+		RequireHardwareMFA: viper.GetBool("aws.iam.root.policies.require_hardware_mfa"),
+	}
+}
+
+func certsPolicy() certs.Policy {
+	return certs.Policy{
+		ExpiryDays:               viper.GetInt("aws.iam.certs.policies.expiry_days"),
+		SSHKeyMaxDays:            viper.GetInt("aws.iam.certs.policies.ssh_key_max_days"),
+		AllowSigningCertificates: viper.GetBool("aws.iam.certs.policies.allow_signing_certificates"),
+	}
+}
+
+func keysPolicy() keys.Policy {
+	return keys.Policy{
+		MaxAgeDays:    viper.GetInt("aws.iam.keys.policies.max_age_days"),
+		MaxUnusedDays: viper.GetInt("aws.iam.keys.policies.max_unused_days"),
+	}
+}
+
+func instancePolicy() hygiene.Policy {
+	return hygiene.Policy{
+		MaxStoppedDays:  viper.GetInt("aws.ec2.instances.policies.max_stopped_days"),
+		MaxImageAgeDays: viper.GetInt("aws.ec2.instances.policies.max_image_age_days"),
+		RequiredTags:    viper.GetStringSlice("aws.ec2.instances.policies.required_tags"),
+	}
+}
+
+func userDataDetectors() []string {
+	return viper.GetStringSlice("aws.ec2.userdata.detectors")
+}
+
+func unusedMaxDays() int {
+	return viper.GetInt("aws.iam.unused.policies.max_days")
+}
+
+func s3Policy() s3.Policy {
+	return s3.Policy{
+		SampleObjects: viper.GetInt("aws.s3.policies.sample_objects"),
+		Sampling: s3.Sampling{
+			MaxKeys:        viper.GetInt("aws.s3.policies.sampling.max_keys"),
+			Patterns:       viper.GetStringSlice("aws.s3.policies.sampling.patterns"),
+			Download:       viper.GetBool("aws.s3.policies.sampling.download"),
+			MaxDownloads:   viper.GetInt("aws.s3.policies.sampling.max_downloads"),
+			MaxObjectBytes: viper.GetInt64("aws.s3.policies.sampling.max_object_bytes"),
+			Detectors:      secrets.Detectors(viper.GetStringSlice("aws.s3.policies.sampling.detectors")...),
+		},
+	}
+}
+
+func s3Rules() s3.Rules {
+	return s3.Rules{
+		Encryption:       viper.GetBool("aws.s3.rules.encryption"),
+		KMSOnly:          viper.GetBool("aws.s3.rules.kms_only"),
+		Versioning:       viper.GetBool("aws.s3.rules.versioning"),
+		Logging:          viper.GetBool("aws.s3.rules.logging"),
+		MFADelete:        viper.GetBool("aws.s3.rules.mfa_delete"),
+		SensitiveBuckets: viper.GetStringSlice("aws.s3.rules.sensitive_buckets"),
+		SecureTransport:  viper.GetBool("aws.s3.rules.secure_transport"),
+	}
+}
+
+func s3Exposure() s3.Exposure {
+	return s3.Exposure{
+		TrustedAccounts:       trustedAccounts(),
+		OrganizationID:        viper.GetString("aws.organization.id"),
+		PublicWebsiteTagKey:   viper.GetString("aws.s3.exposure.public_website_tag.key"),
+		PublicWebsiteTagValue: viper.GetString("aws.s3.exposure.public_website_tag.value"),
+	}
+}
+
 func getRegions() []string {
 	return viper.GetStringSlice("aws.regions")
 }
 
+// trustedAccounts returns the numbers of all configured accounts and of any
+// additional accounts that belong to the organization.
+func trustedAccounts() []string {
+	var trusted []string
+	for _, account := range accounts {
+		trusted = append(trusted, account.Number)
+	}
+	return append(trusted, viper.GetStringSlice("aws.organization.accounts")...)
+}
+
 // func report(data string) {
 // 	api := slack.New(viper.GetString("reporters.slack.token"))
 // 	slackChannel := viper.GetString("reporters.slack.channel")